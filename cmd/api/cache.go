@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCache is an in-memory cache whose entries expire ttl after being set. It
+// holds at most maxEntries: when full, expired entries are dropped first and,
+// if that frees nothing, the whole cache is cleared rather than tracking usage
+// for an LRU.
+type ttlCache[V any] struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry[V]
+}
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration, maxEntries int) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]ttlCacheEntry[V]),
+	}
+}
+
+func (c *ttlCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key]
+	if !found || time.Now().After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+
+	return entry.value, true
+}
+
+func (c *ttlCache[V]) Set(key string, value V) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	if _, found := c.entries[key]; !found && len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			clear(c.entries)
+		}
+	}
+
+	c.entries[key] = ttlCacheEntry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,70 @@
+package main
+
+import "github.com/JLL32/nuitee/internal/data"
+
+const geoJSONContentType = "application/geo+json"
+
+// geoJSONPoint is a GeoJSON Point geometry. Coordinates are in
+// [longitude, latitude] order, as required by RFC 7946.
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type hotelFeatureProperties struct {
+	HotelName   string   `json:"hotel_name"`
+	Stars       int      `json:"stars"`
+	Rating      float64  `json:"rating"`
+	ReviewCount int      `json:"review_count"`
+	MainImageTh string   `json:"main_image_th"`
+	City        string   `json:"city"`
+	Country     string   `json:"country"`
+	DistanceKm  *float64 `json:"distance_km,omitempty"`
+}
+
+type hotelFeature struct {
+	Type       string                 `json:"type"`
+	ID         int                    `json:"id"`
+	Geometry   *geoJSONPoint          `json:"geometry"`
+	Properties hotelFeatureProperties `json:"properties"`
+}
+
+// hotelFeatureCollection renders a page of hotels as a GeoJSON
+// FeatureCollection. Hotels without coordinates keep their place in the page
+// with a null geometry, and the pagination metadata is carried as a foreign
+// member so map clients can page through results the same way as JSON ones.
+func hotelFeatureCollection(hotels []*data.Hotel, metadata data.Metadata) envelope {
+	features := make([]hotelFeature, 0, len(hotels))
+
+	for _, hotel := range hotels {
+		feature := hotelFeature{
+			Type: "Feature",
+			ID:   hotel.HotelID,
+			Properties: hotelFeatureProperties{
+				HotelName:   hotel.HotelName,
+				Stars:       hotel.Stars,
+				Rating:      hotel.Rating,
+				ReviewCount: hotel.ReviewCount,
+				MainImageTh: hotel.MainImageTh,
+				City:        hotel.Address.City,
+				Country:     hotel.Address.Country,
+				DistanceKm:  hotel.DistanceKm,
+			},
+		}
+
+		if hotel.Latitude != nil && hotel.Longitude != nil {
+			feature.Geometry = &geoJSONPoint{
+				Type:        "Point",
+				Coordinates: [2]float64{*hotel.Longitude, *hotel.Latitude},
+			}
+		}
+
+		features = append(features, feature)
+	}
+
+	return envelope{
+		"type":     "FeatureCollection",
+		"features": features,
+		"metadata": metadata,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -41,7 +42,9 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, data envelo
 
 	maps.Copy(w.Header(), headers)
 
-	w.Header().Set("Content-Type", "application/json")
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.WriteHeader(status)
 	w.Write(js)
 
@@ -134,6 +137,110 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
+func (app *application) readFloat(qs url.Values, key string, defaultValue float64, v *validator.Validator) float64 {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		v.AddError(key, "must be a number")
+		return defaultValue
+	}
+
+	return f
+}
+
+// readIntCSV reads a comma-separated list of integers, returning nil when the
+// key is missing or any element is not an integer.
+func (app *application) readIntCSV(qs url.Values, key string, v *validator.Validator) []int {
+	parts := app.readCSV(qs, key, nil)
+	if parts == nil {
+		return nil
+	}
+
+	ints := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			v.AddError(key, "must be a comma-separated list of integers")
+			return nil
+		}
+		ints[i] = n
+	}
+
+	return ints
+}
+
+// readBool reads an optional boolean, returning nil when the key is missing.
+func (app *application) readBool(qs url.Values, key string, v *validator.Validator) *bool {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return nil
+	}
+
+	return &b
+}
+
+// readFloatCSV reads a comma-separated list of exactly n numbers, such as a
+// bounding box. It returns nil when the key is missing or malformed.
+func (app *application) readFloatCSV(qs url.Values, key string, n int, v *validator.Validator) []float64 {
+	parts := app.readCSV(qs, key, nil)
+	if parts == nil {
+		return nil
+	}
+
+	if len(parts) != n {
+		v.AddError(key, fmt.Sprintf("must contain %d comma-separated numbers", n))
+		return nil
+	}
+
+	floats := make([]float64, n)
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			v.AddError(key, fmt.Sprintf("must contain %d comma-separated numbers", n))
+			return nil
+		}
+		floats[i] = f
+	}
+
+	return floats
+}
+
+// accepts reports whether the request's Accept header explicitly lists the
+// given media type with a non-zero quality.
+func (app *application) accepts(r *http.Request, mediaType string) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(header, ",") {
+			fields := strings.Split(part, ";")
+			if !strings.EqualFold(strings.TrimSpace(fields[0]), mediaType) {
+				continue
+			}
+
+			q := 1.0
+			for _, param := range fields[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "q") {
+					q, _ = strconv.ParseFloat(value, 64)
+				}
+			}
+			if q > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func (app *application) background(fn func()) {
 	app.wg.Add(1)
 	go func() {
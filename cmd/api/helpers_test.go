@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -171,6 +172,23 @@ func TestWriteJSON(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "caller-set Content-Type is kept",
+			status: http.StatusOK,
+			data: envelope{
+				"type": "FeatureCollection",
+			},
+			headers: http.Header{
+				"Content-Type": []string{"application/geo+json"},
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				contentType := rr.Header().Get("Content-Type")
+				if contentType != "application/geo+json" {
+					t.Errorf("expected Content-Type 'application/geo+json', got %s", contentType)
+				}
+			},
+		},
 		{
 			name:   "empty data",
 			status: http.StatusNoContent,
@@ -636,6 +654,352 @@ func TestReadInt(t *testing.T) {
 	}
 }
 
+func TestReadFloat(t *testing.T) {
+	app, _, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	tests := []struct {
+		name         string
+		queryValues  url.Values
+		key          string
+		defaultValue float64
+		expected     float64
+		expectError  bool
+	}{
+		{
+			name: "valid number",
+			queryValues: url.Values{
+				"lat": []string{"48.8566"},
+			},
+			key:          "lat",
+			defaultValue: 0,
+			expected:     48.8566,
+			expectError:  false,
+		},
+		{
+			name: "integer value",
+			queryValues: url.Values{
+				"lat": []string{"-12"},
+			},
+			key:          "lat",
+			defaultValue: 0,
+			expected:     -12,
+			expectError:  false,
+		},
+		{
+			name:         "missing key returns default",
+			queryValues:  url.Values{},
+			key:          "radius_km",
+			defaultValue: 10,
+			expected:     10,
+			expectError:  false,
+		},
+		{
+			name: "invalid number",
+			queryValues: url.Values{
+				"lat": []string{"north"},
+			},
+			key:          "lat",
+			defaultValue: 0,
+			expected:     0,
+			expectError:  true,
+		},
+		{
+			name: "NaN",
+			queryValues: url.Values{
+				"lat": []string{"NaN"},
+			},
+			key:          "lat",
+			defaultValue: 0,
+			expected:     0,
+			expectError:  true,
+		},
+		{
+			name: "infinity",
+			queryValues: url.Values{
+				"radius_km": []string{"+Inf"},
+			},
+			key:          "radius_km",
+			defaultValue: 10,
+			expected:     10,
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			result := app.readFloat(tt.queryValues, tt.key, tt.defaultValue, v)
+
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+
+			if tt.expectError && v.Valid() {
+				t.Error("expected validation error but validator is valid")
+			}
+
+			if !tt.expectError && !v.Valid() {
+				t.Errorf("unexpected validation error: %v", v.Errors)
+			}
+		})
+	}
+}
+
+func TestReadFloatCSV(t *testing.T) {
+	app, _, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	tests := []struct {
+		name        string
+		queryValues url.Values
+		expected    []float64
+		expectError bool
+	}{
+		{
+			name: "valid bounding box",
+			queryValues: url.Values{
+				"bbox": []string{"2.25, 48.8,2.42,48.9"},
+			},
+			expected:    []float64{2.25, 48.8, 2.42, 48.9},
+			expectError: false,
+		},
+		{
+			name:        "missing key",
+			queryValues: url.Values{},
+			expected:    nil,
+			expectError: false,
+		},
+		{
+			name: "too few numbers",
+			queryValues: url.Values{
+				"bbox": []string{"2.25,48.8,2.42"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "too many numbers",
+			queryValues: url.Values{
+				"bbox": []string{"2.25,48.8,2.42,48.9,1"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "not a number",
+			queryValues: url.Values{
+				"bbox": []string{"2.25,48.8,east,48.9"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "NaN",
+			queryValues: url.Values{
+				"bbox": []string{"2.25,NaN,2.42,48.9"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "infinity",
+			queryValues: url.Values{
+				"bbox": []string{"-Inf,48.8,2.42,48.9"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			result := app.readFloatCSV(tt.queryValues, "bbox", 4, v)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+
+			if tt.expectError && v.Valid() {
+				t.Error("expected validation error but validator is valid")
+			}
+
+			if !tt.expectError && !v.Valid() {
+				t.Errorf("unexpected validation error: %v", v.Errors)
+			}
+		})
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	app, _, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	tests := []struct {
+		name     string
+		accept   []string
+		expected bool
+	}{
+		{name: "no Accept header", accept: nil, expected: false},
+		{name: "exact match", accept: []string{"application/geo+json"}, expected: true},
+		{name: "case insensitive", accept: []string{"Application/GEO+JSON"}, expected: true},
+		{name: "one of several", accept: []string{"application/json, application/geo+json;q=0.9"}, expected: true},
+		{name: "in a second header", accept: []string{"text/html", "application/geo+json"}, expected: true},
+		{name: "other type", accept: []string{"application/json"}, expected: false},
+		{name: "wildcard", accept: []string{"*/*"}, expected: false},
+		{name: "q=0 refuses it", accept: []string{"application/geo+json;q=0"}, expected: false},
+		{name: "q=0 with spaces", accept: []string{"application/json, application/geo+json; q=0"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/hotels", nil)
+			for _, accept := range tt.accept {
+				req.Header.Add("Accept", accept)
+			}
+
+			if got := app.accepts(req, "application/geo+json"); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReadIntCSV(t *testing.T) {
+	app, _, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	tests := []struct {
+		name        string
+		queryValues url.Values
+		expected    []int
+		expectError bool
+	}{
+		{
+			name: "valid list",
+			queryValues: url.Values{
+				"stars": []string{"4, 5"},
+			},
+			expected:    []int{4, 5},
+			expectError: false,
+		},
+		{
+			name: "single value",
+			queryValues: url.Values{
+				"stars": []string{"3"},
+			},
+			expected:    []int{3},
+			expectError: false,
+		},
+		{
+			name:        "missing key",
+			queryValues: url.Values{},
+			expected:    nil,
+			expectError: false,
+		},
+		{
+			name: "not an integer",
+			queryValues: url.Values{
+				"stars": []string{"4,five"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "empty element",
+			queryValues: url.Values{
+				"stars": []string{"4,,5"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			result := app.readIntCSV(tt.queryValues, "stars", v)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+
+			if tt.expectError && v.Valid() {
+				t.Error("expected validation error but validator is valid")
+			}
+
+			if !tt.expectError && !v.Valid() {
+				t.Errorf("unexpected validation error: %v", v.Errors)
+			}
+		})
+	}
+}
+
+func TestReadBool(t *testing.T) {
+	app, _, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	yes, no := true, false
+
+	tests := []struct {
+		name        string
+		queryValues url.Values
+		expected    *bool
+		expectError bool
+	}{
+		{
+			name: "true",
+			queryValues: url.Values{
+				"pets_allowed": []string{"true"},
+			},
+			expected:    &yes,
+			expectError: false,
+		},
+		{
+			name: "false as 0",
+			queryValues: url.Values{
+				"pets_allowed": []string{"0"},
+			},
+			expected:    &no,
+			expectError: false,
+		},
+		{
+			name:        "missing key",
+			queryValues: url.Values{},
+			expected:    nil,
+			expectError: false,
+		},
+		{
+			name: "not a boolean",
+			queryValues: url.Values{
+				"pets_allowed": []string{"maybe"},
+			},
+			expected:    nil,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			result := app.readBool(tt.queryValues, "pets_allowed", v)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+
+			if tt.expectError && v.Valid() {
+				t.Error("expected validation error but validator is valid")
+			}
+
+			if !tt.expectError && !v.Valid() {
+				t.Errorf("unexpected validation error: %v", v.Errors)
+			}
+		})
+	}
+}
+
 func TestBackground(t *testing.T) {
 	app, _, cleanup := newTestApplication(t)
 	defer cleanup()
@@ -3,11 +3,15 @@ package main
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/JLL32/nuitee/internal/data"
 	"github.com/JLL32/nuitee/internal/validator"
 )
 
+// getHotelHandler returns a hotel. Its photos, facilities, rooms and
+// policies are only loaded when named in the include parameter, e.g.
+// include=photos,rooms.
 func (app *application) getHotelHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := app.readIDParam(r, "hotelID")
 	if err != nil {
@@ -15,6 +19,19 @@ func (app *application) getHotelHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	v := validator.New()
+
+	include := app.readCSV(r.URL.Query(), "include", []string{})
+	for _, section := range include {
+		v.Check(validator.PermittedValue(section, data.HotelDetailSections...), "include", "must only contain photos, facilities, rooms or policies")
+	}
+	v.Check(validator.Unique(include), "include", "must not contain duplicate values")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	hotel, err := app.models.Hotels.Get(id)
 	if err != nil {
 		switch {
@@ -26,15 +43,25 @@ func (app *application) getHotelHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	err = app.models.HotelDetails.Load(hotel, include...)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"hotel": hotel}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// listHotelsHandler lists hotels. With lat and lng each hotel gets its
+// distance from that point and sort=distance is allowed; radius_km further
+// limits the results to hotels within that distance.
 func (app *application) listHotelsHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Search  string
+		data.HotelQuery
+		Facets  []string
 		Filters data.Filters
 	}
 
@@ -43,25 +70,164 @@ func (app *application) listHotelsHandler(w http.ResponseWriter, r *http.Request
 	qs := r.URL.Query()
 
 	input.Search = app.readString(qs, "search", "")
+	input.SearchMode = app.readString(qs, "q_mode", data.SearchPlain)
+	if fuzzy := app.readBool(qs, "fuzzy", v); fuzzy != nil {
+		input.Fuzzy = *fuzzy
+	}
+	if qs.Has("lat") || qs.Has("lng") || qs.Has("radius_km") {
+		input.Near = &data.Near{
+			Lat:      app.readFloat(qs, "lat", 0, v),
+			Lng:      app.readFloat(qs, "lng", 0, v),
+			RadiusKm: app.readFloat(qs, "radius_km", 0, v),
+		}
+	}
+	if bbox := app.readFloatCSV(qs, "bbox", 4, v); bbox != nil {
+		input.BBox = &data.BBox{MinLng: bbox[0], MinLat: bbox[1], MaxLng: bbox[2], MaxLat: bbox[3]}
+	}
+	input.Country = app.readString(qs, "country", "")
+	input.City = app.readString(qs, "city", "")
+	input.Stars = app.readIntCSV(qs, "stars", v)
+	if qs.Get("min_stars") != "" {
+		minStars := app.readInt(qs, "min_stars", 0, v)
+		input.MinStars = &minStars
+	}
+	if qs.Get("max_stars") != "" {
+		maxStars := app.readInt(qs, "max_stars", 5, v)
+		input.MaxStars = &maxStars
+	}
+	if qs.Get("min_rating") != "" {
+		minRating := app.readFloat(qs, "min_rating", 0, v)
+		input.MinRating = &minRating
+	}
+	if qs.Get("max_rating") != "" {
+		maxRating := app.readFloat(qs, "max_rating", 10, v)
+		input.MaxRating = &maxRating
+	}
+	if qs.Get("min_review_count") != "" {
+		minReviewCount := app.readInt(qs, "min_review_count", 0, v)
+		input.MinReviewCount = &minReviewCount
+	}
+	input.PetsAllowed = app.readBool(qs, "pets_allowed", v)
+	input.ChildAllowed = app.readBool(qs, "child_allowed", v)
+	input.Facets = app.readCSV(qs, "facets", nil)
 	input.Filters.Page = app.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
 	input.Filters.Sort = app.readString(qs, "sort", "hotel_id")
-	input.Filters.SortSafelist = []string{"hotel_id", "name", "country", "city", "rating", "starts", "-hotel_id", "-name", "-country", "-city", "-rating", "-starts"}
+	input.Filters.SortSafelist = []string{"hotel_id", "name", "country", "city", "rating", "starts", "distance", "relevance", "-hotel_id", "-name", "-country", "-city", "-rating", "-starts", "-distance"}
+
+	if input.Near != nil {
+		v.Check(qs.Get("lat") != "", "lat", "must be provided with lng or radius_km")
+		v.Check(qs.Get("lng") != "", "lng", "must be provided with lat or radius_km")
+	} else {
+		v.Check(strings.TrimPrefix(input.Filters.Sort, "-") != "distance", "sort", "distance requires lat and lng")
+	}
+	if input.Search == "" {
+		v.Check(input.Filters.Sort != "relevance", "sort", "relevance requires search")
+	}
+
+	data.ValidateHotelQuery(v, input.HotelQuery)
+
+	for _, facet := range input.Facets {
+		v.Check(validator.PermittedValue(facet, data.HotelFacets...), "facets", "must only contain "+strings.Join(data.HotelFacets, ", "))
+	}
+	v.Check(validator.Unique(input.Facets), "facets", "must not contain duplicate values")
 
 	if data.ValidateFilters(v, input.Filters); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	hotels, metadata, err := app.models.Hotels.GetAll(input.Search, input.Filters)
+	hotels, metadata, err := app.models.Hotels.GetAll(input.HotelQuery, input.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"metadata": metadata, "hotels": hotels}, nil)
+	var facets map[string][]data.FacetCount
+	if len(input.Facets) > 0 {
+		facets, err = app.models.Hotels.Facets(input.HotelQuery, input.Facets)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	headers := make(http.Header)
+	headers.Set("Vary", "Accept")
+
+	if app.accepts(r, geoJSONContentType) {
+		headers.Set("Content-Type", geoJSONContentType)
+
+		env := hotelFeatureCollection(hotels, metadata)
+		if facets != nil {
+			env["facets"] = facets
+		}
+
+		err = app.writeJSON(w, http.StatusOK, env, headers)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	env := envelope{"metadata": metadata, "hotels": hotels}
+	if facets != nil {
+		env["facets"] = facets
+	}
+
+	err = app.writeJSON(w, http.StatusOK, env, headers)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 }
+
+func (app *application) listHotelHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Field string
+		data.Filters
+	}
+
+	id, err := app.readIDParam(r, "hotelID")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Field = app.readString(qs, "field", "")
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-changed_at")
+	input.Filters.SortSafelist = []string{"id", "changed_at", "field", "-id", "-changed_at", "-field"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	hotel, err := app.models.Hotels.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	changes, metadata, err := app.models.HotelChanges.GetAllForHotel(int64(hotel.HotelID), input.Field, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"metadata": metadata, "changes": changes}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
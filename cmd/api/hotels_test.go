@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,19 +57,19 @@ func TestGetHotelHandler(t *testing.T) {
 			name:    "valid hotel ID",
 			hotelID: "123",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 					WithArgs(int64(123)).
 					WillReturnRows(sqlmock.NewRows([]string{
 						"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 						"city", "state", "country", "postal_code", "stars", "rating",
-						"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+						"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
 					}).AddRow(
 						expectedHotel.HotelID, expectedHotel.MainImageTh, expectedHotel.HotelName,
 						expectedHotel.Phone, expectedHotel.Email, expectedHotel.Address.Address,
 						expectedHotel.Address.City, expectedHotel.Address.State, expectedHotel.Address.Country,
 						expectedHotel.Address.PostalCode, expectedHotel.Stars, expectedHotel.Rating,
 						expectedHotel.ReviewCount, expectedHotel.ChildAllowed, expectedHotel.PetsAllowed,
-						expectedHotel.Description, expectedHotel.CreatedAt, expectedHotel.UpdatedAt,
+						expectedHotel.Description, nil, nil, expectedHotel.CreatedAt, expectedHotel.UpdatedAt,
 					))
 			},
 			expectedStatus: http.StatusOK,
@@ -93,7 +95,7 @@ func TestGetHotelHandler(t *testing.T) {
 			name:    "hotel not found",
 			hotelID: "999",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 					WithArgs(int64(999)).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -133,7 +135,7 @@ func TestGetHotelHandler(t *testing.T) {
 			name:    "database error",
 			hotelID: "123",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 					WithArgs(int64(123)).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -249,7 +251,7 @@ func TestListHotelsHandler(t *testing.T) {
 				rows := sqlmock.NewRows([]string{
 					"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 					"city", "state", "country", "postal_code", "stars", "rating",
-					"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+					"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
 				})
 
 				for _, hotel := range expectedHotels {
@@ -259,12 +261,12 @@ func TestListHotelsHandler(t *testing.T) {
 						hotel.Address.City, hotel.Address.State, hotel.Address.Country,
 						hotel.Address.PostalCode, hotel.Stars, hotel.Rating,
 						hotel.ReviewCount, hotel.ChildAllowed, hotel.PetsAllowed,
-						hotel.Description, hotel.CreatedAt, hotel.UpdatedAt,
+						hotel.Description, nil, nil, hotel.CreatedAt, hotel.UpdatedAt, nil,
 					)
 				}
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-					WithArgs("", 20, 0).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+					WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 					WillReturnRows(rows)
 			},
 			expectedStatus: http.StatusOK,
@@ -294,7 +296,8 @@ func TestListHotelsHandler(t *testing.T) {
 				rows := sqlmock.NewRows([]string{
 					"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 					"city", "state", "country", "postal_code", "stars", "rating",
-					"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+					"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+					"hotel_name_snippet", "description_snippet",
 				})
 
 				// Add one hotel for search results
@@ -305,11 +308,12 @@ func TestListHotelsHandler(t *testing.T) {
 					hotel.Address.City, hotel.Address.State, hotel.Address.Country,
 					hotel.Address.PostalCode, hotel.Stars, hotel.Rating,
 					hotel.ReviewCount, hotel.ChildAllowed, hotel.PetsAllowed,
-					hotel.Description, hotel.CreatedAt, hotel.UpdatedAt,
+					hotel.Description, nil, nil, hotel.CreatedAt, hotel.UpdatedAt, nil,
+					hotel.HotelName, hotel.Description,
 				)
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-					WithArgs("luxury", 20, 0).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance, ts_headline(.+) FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+					WithArgs("luxury", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 					WillReturnRows(rows)
 			},
 			expectedStatus: http.StatusOK,
@@ -335,11 +339,11 @@ func TestListHotelsHandler(t *testing.T) {
 				rows := sqlmock.NewRows([]string{
 					"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 					"city", "state", "country", "postal_code", "stars", "rating",
-					"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+					"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
 				})
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-					WithArgs("", 10, 10).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+					WithArgs("", 10, 10, nil, nil, nil, nil, nil, nil, nil).
 					WillReturnRows(rows)
 			},
 			expectedStatus: http.StatusOK,
@@ -419,8 +423,8 @@ func TestListHotelsHandler(t *testing.T) {
 			name:        "database error",
 			queryParams: "",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-					WithArgs("", 20, 0).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+					WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 					WillReturnError(sql.ErrConnDone)
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -476,6 +480,141 @@ func TestListHotelsHandler(t *testing.T) {
 	}
 }
 
+func TestListHotelHistoryHandler(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	hotelColumns := []string{
+		"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+		"city", "state", "country", "postal_code", "stars", "rating",
+		"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
+	}
+
+	changeColumns := []string{"count", "id", "hotel_id", "field", "old_value", "new_value", "changed_at"}
+
+	expectHotel := func(id int64) {
+		mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1`).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows(hotelColumns).AddRow(
+				id, "", "Test Hotel", "", "", "", "", "", "", "", 5, 4.5, 0, false, false, "", nil, nil, time.Now(), time.Now(),
+			))
+	}
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func()
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "default parameters",
+			url:  "/v1/hotels/123/history",
+			setupMock: func() {
+				expectHotel(123)
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), (.+) FROM hotel_changes WHERE hotel_id = \$1 AND \(field = \$2 OR \$2 = ''\) ORDER BY changed_at DESC, id ASC`).
+					WithArgs(int64(123), "", 20, 0).
+					WillReturnRows(sqlmock.NewRows(changeColumns).
+						AddRow(2, 2, 123, "stars", "4", "5", time.Now()).
+						AddRow(2, 1, 123, "hotel_name", "Old Hotel", "Test Hotel", time.Now().Add(-time.Hour)))
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response struct {
+					Changes  []data.HotelChange `json:"changes"`
+					Metadata data.Metadata      `json:"metadata"`
+				}
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if len(response.Changes) != 2 {
+					t.Fatalf("expected 2 changes, got %d", len(response.Changes))
+				}
+
+				if response.Changes[0].Field != "stars" || response.Changes[0].OldValue != "4" || response.Changes[0].NewValue != "5" {
+					t.Errorf("unexpected change: %+v", response.Changes[0])
+				}
+
+				if response.Metadata.TotalRecords != 2 {
+					t.Errorf("expected 2 total records, got %d", response.Metadata.TotalRecords)
+				}
+			},
+		},
+		{
+			name: "filtered by field",
+			url:  "/v1/hotels/123/history?field=stars&sort=changed_at",
+			setupMock: func() {
+				expectHotel(123)
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), (.+) FROM hotel_changes (.+) ORDER BY changed_at ASC, id ASC`).
+					WithArgs(int64(123), "stars", 20, 0).
+					WillReturnRows(sqlmock.NewRows(changeColumns))
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response struct {
+					Changes []data.HotelChange `json:"changes"`
+				}
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if response.Changes == nil || len(response.Changes) != 0 {
+					t.Errorf("expected an empty changes array, got %v", response.Changes)
+				}
+			},
+		},
+		{
+			name: "hotel not found",
+			url:  "/v1/hotels/999/history",
+			setupMock: func() {
+				mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1`).
+					WithArgs(int64(999)).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			checkResponse:  func(t *testing.T, rr *httptest.ResponseRecorder) {},
+		},
+		{
+			name:           "invalid sort",
+			url:            "/v1/hotels/123/history?sort=old_value",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse:  func(t *testing.T, rr *httptest.ResponseRecorder) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+
+			router := httprouter.New()
+			router.HandlerFunc(http.MethodGet, "/v1/hotels/:hotelID/history", app.listHotelHistoryHandler)
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			tt.checkResponse(t, rr)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkGetHotelHandler(b *testing.B) {
 	app, mock, cleanup := newTestApplicationForBenchmark(b)
@@ -506,19 +645,19 @@ func BenchmarkGetHotelHandler(b *testing.B) {
 	}
 
 	for i := 0; i < b.N; i++ {
-		mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+		mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 			WithArgs(int64(123)).
 			WillReturnRows(sqlmock.NewRows([]string{
 				"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 				"city", "state", "country", "postal_code", "stars", "rating",
-				"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+				"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
 			}).AddRow(
 				expectedHotel.HotelID, expectedHotel.MainImageTh, expectedHotel.HotelName,
 				expectedHotel.Phone, expectedHotel.Email, expectedHotel.Address.Address,
 				expectedHotel.Address.City, expectedHotel.Address.State, expectedHotel.Address.Country,
 				expectedHotel.Address.PostalCode, expectedHotel.Stars, expectedHotel.Rating,
 				expectedHotel.ReviewCount, expectedHotel.ChildAllowed, expectedHotel.PetsAllowed,
-				expectedHotel.Description, expectedHotel.CreatedAt, expectedHotel.UpdatedAt,
+				expectedHotel.Description, nil, nil, expectedHotel.CreatedAt, expectedHotel.UpdatedAt,
 			))
 	}
 
@@ -542,15 +681,15 @@ func BenchmarkListHotelsHandler(b *testing.B) {
 		rows := sqlmock.NewRows([]string{
 			"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 			"city", "state", "country", "postal_code", "stars", "rating",
-			"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+			"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
 		}).AddRow(
 			1, 123, "image.jpg", "Test Hotel", "123-456-7890", "test@hotel.com", "123 Main St",
 			"Test City", "Test State", "Test Country", "12345", 5, 4.5,
-			100, true, false, "A wonderful test hotel", time.Now(), time.Now(),
+			100, true, false, "A wonderful test hotel", nil, nil, time.Now(), time.Now(), nil,
 		)
 
-		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-			WithArgs("", 20, 0).
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+			WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 			WillReturnRows(rows)
 	}
 
@@ -564,4 +703,571 @@ func BenchmarkListHotelsHandler(b *testing.B) {
 		rr := httptest.NewRecorder()
 		router.ServeHTTP(rr, req)
 	}
-}
\ No newline at end of file
+}
+func TestGetHotelHandler_Include(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	hotelColumns := []string{
+		"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+		"city", "state", "country", "postal_code", "stars", "rating",
+		"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
+	}
+	now := time.Now()
+
+	t.Run("photos and policies", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1`).
+			WithArgs(int64(123)).
+			WillReturnRows(sqlmock.NewRows(hotelColumns).
+				AddRow(123, "", "Test Hotel", "", "", "", "", "", "", "", 4, 8.5, 10, true, false, "", nil, nil, now, now))
+		mock.ExpectQuery(`SELECT (.+) FROM hotel_photos WHERE hotel_id = \$1 ORDER BY position`).
+			WithArgs(123).
+			WillReturnRows(sqlmock.NewRows([]string{"url", "hd_url", "description", "class", "main_photo", "score"}).
+				AddRow("https://img.example.com/1.jpg", "https://img.example.com/1_hd.jpg", "Lobby", "Lobby", true, 8.1))
+		mock.ExpectQuery(`SELECT (.+) FROM hotel_policies WHERE hotel_id = \$1 ORDER BY position`).
+			WithArgs(123).
+			WillReturnRows(sqlmock.NewRows([]string{"policy_type", "name", "description"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels/123?include=photos,policies", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var response struct {
+			Hotel map[string]json.RawMessage `json:"hotel"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("could not unmarshal response: %v", err)
+		}
+
+		var photos []data.HotelPhoto
+		if err := json.Unmarshal(response.Hotel["photos"], &photos); err != nil || len(photos) != 1 || !photos[0].MainPhoto {
+			t.Errorf("unexpected photos: %s", response.Hotel["photos"])
+		}
+		if string(response.Hotel["policies"]) != "[]" {
+			t.Errorf("expected empty policies to be rendered as [], got %s", response.Hotel["policies"])
+		}
+		if _, ok := response.Hotel["rooms"]; ok {
+			t.Error("expected rooms to be left out when not included")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	for _, include := range []string{"amenities", "photos,photos"} {
+		t.Run("invalid "+include, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/hotels/123?include="+include, nil)
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+		})
+	}
+}
+
+func TestListHotelsHandler_Near(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	columns := []string{
+		"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+		"city", "state", "country", "postal_code", "stars", "rating",
+		"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+	}
+	now := time.Now()
+
+	t.Run("radius search sorted by distance", func(t *testing.T) {
+		mock.ExpectQuery(`FROM hotels CROSS JOIN LATERAL (.+) ORDER BY distance ASC NULLS LAST, hotel_id ASC`).
+			WithArgs("", 20, 0, 43.7, 7.26, 2.5, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, 123, "", "Harbour View", "", "", "", "Nice", "", "fr", "", 4, 8.7, 3, true, false, "", 43.695, 7.265, now, now, 0.68))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?lat=43.7&lng=7.26&radius_km=2.5&sort=distance", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var response struct {
+			Hotels []data.Hotel `json:"hotels"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("could not unmarshal response: %v", err)
+		}
+		if len(response.Hotels) != 1 || response.Hotels[0].DistanceKm == nil || *response.Hotels[0].DistanceKm != 0.68 {
+			t.Errorf("expected distance_km in the results, got %s", rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{name: "lat without lng", query: "lat=43.7", field: "lng"},
+		{name: "radius without point", query: "radius_km=5", field: "lat"},
+		{name: "latitude out of range", query: "lat=91&lng=0", field: "lat"},
+		{name: "not a number", query: "lat=north&lng=0", field: "lat"},
+		{name: "negative radius", query: "lat=0&lng=0&radius_km=-1", field: "radius_km"},
+		{name: "distance sort without point", query: "sort=distance", field: "sort"},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/hotels?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+
+			var response struct {
+				Error map[string]string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if _, ok := response.Error[tt.field]; !ok {
+				t.Errorf("expected an error for %s, got %v", tt.field, response.Error)
+			}
+		})
+	}
+}
+
+func TestListHotelsHandler_BBox(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	columns := []string{
+		"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+		"city", "state", "country", "postal_code", "stars", "rating",
+		"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+	}
+	now := time.Now()
+
+	t.Run("geojson", func(t *testing.T) {
+		mock.ExpectQuery(`FROM hotels (.+) AND \(\$7::double precision IS NULL OR (.+) ORDER BY hotel_id ASC`).
+			WithArgs("", 20, 0, nil, nil, nil, 7.2, 43.6, 7.3, 43.8).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(2, 123, "https://example.com/123.jpg", "Harbour View", "", "", "", "Nice", "", "fr", "", 4, 8.7, 3, true, false, "", 43.695, 7.265, now, now, nil).
+				AddRow(2, 456, "", "Old Town Inn", "", "", "", "Nice", "", "fr", "", 3, 7.9, 1, true, false, "", nil, nil, now, now, nil))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?bbox=7.2,43.6,7.3,43.8", nil)
+		req.Header.Set("Accept", "application/geo+json, application/json;q=0.9")
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "application/geo+json" {
+			t.Errorf("expected a GeoJSON content type, got %q", ct)
+		}
+
+		var response struct {
+			Type     string `json:"type"`
+			Features []struct {
+				Type     string `json:"type"`
+				ID       int    `json:"id"`
+				Geometry *struct {
+					Type        string    `json:"type"`
+					Coordinates []float64 `json:"coordinates"`
+				} `json:"geometry"`
+				Properties map[string]any `json:"properties"`
+			} `json:"features"`
+			Metadata data.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("could not unmarshal response: %v", err)
+		}
+
+		if response.Type != "FeatureCollection" || len(response.Features) != 2 {
+			t.Fatalf("expected a FeatureCollection with 2 features, got %s", rr.Body)
+		}
+		first := response.Features[0]
+		if first.ID != 123 || first.Geometry == nil || first.Geometry.Coordinates[0] != 7.265 || first.Geometry.Coordinates[1] != 43.695 {
+			t.Errorf("expected a [lng, lat] point for hotel 123, got %+v", first)
+		}
+		if first.Properties["hotel_name"] != "Harbour View" || first.Properties["stars"] != 4.0 || first.Properties["rating"] != 8.7 {
+			t.Errorf("unexpected properties: %v", first.Properties)
+		}
+		if response.Features[1].Geometry != nil {
+			t.Errorf("expected a null geometry for a hotel without coordinates, got %+v", response.Features[1].Geometry)
+		}
+		if response.Metadata.TotalRecords != 2 {
+			t.Errorf("expected the pagination metadata, got %+v", response.Metadata)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("json by default", func(t *testing.T) {
+		mock.ExpectQuery(`FROM hotels`).
+			WithArgs("", 20, 0, nil, nil, nil, 170.0, -20.0, -170.0, -10.0).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?bbox=170,-20,-170,-10", nil)
+		req.Header.Set("Accept", "application/geo+json;q=0, */*")
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected a JSON content type, got %q", ct)
+		}
+		if rr.Header().Get("Vary") != "Accept" {
+			t.Errorf("expected Vary: Accept, got %q", rr.Header().Get("Vary"))
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		query string
+	}{
+		{name: "too few numbers", query: "bbox=7.2,43.6,7.3"},
+		{name: "not a number", query: "bbox=7.2,43.6,east,43.8"},
+		{name: "latitude out of range", query: "bbox=7.2,-91,7.3,43.8"},
+		{name: "latitudes reversed", query: "bbox=7.2,43.8,7.3,43.6"},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/hotels?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+
+			var response struct {
+				Error map[string]string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if _, ok := response.Error["bbox"]; !ok {
+				t.Errorf("expected an error for bbox, got %v", response.Error)
+			}
+		})
+	}
+}
+
+func TestListHotelsHandler_Attributes(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	t.Run("filters are bound as arguments", func(t *testing.T) {
+		mock.ExpectQuery(`AND lower\(country\) = lower\(\$11\) AND lower\(city\) = lower\(\$12\) AND stars = ANY\(\$13::integer\[\]\) AND rating >= \$14 AND rating <= \$15 AND review_count >= \$16 AND pets_allowed = \$17 AND child_allowed = \$18 ORDER BY`).
+			WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil, "fr", "Nice", "{4,5}", 8.0, 9.5, 10, true, false).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?country=fr&city=Nice&stars=4,5&min_rating=8&max_rating=9.5&min_review_count=10&pets_allowed=true&child_allowed=false", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("star range", func(t *testing.T) {
+		mock.ExpectQuery(`AND stars >= \$11 AND stars <= \$12 ORDER BY`).
+			WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil, 3, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?min_stars=3&max_stars=4", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{name: "stars not integers", query: "stars=4,five", field: "stars"},
+		{name: "stars out of range", query: "stars=7", field: "stars"},
+		{name: "star bounds reversed", query: "min_stars=5&max_stars=2", field: "min_stars"},
+		{name: "rating not a number", query: "min_rating=high", field: "min_rating"},
+		{name: "rating out of range", query: "max_rating=11", field: "max_rating"},
+		{name: "negative review count", query: "min_review_count=-1", field: "min_review_count"},
+		{name: "pets not a boolean", query: "pets_allowed=maybe", field: "pets_allowed"},
+		{name: "country not a code", query: "country=france", field: "country"},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/hotels?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+
+			var response struct {
+				Error map[string]string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if _, ok := response.Error[tt.field]; !ok {
+				t.Errorf("expected an error for %s, got %v", tt.field, response.Error)
+			}
+		})
+	}
+}
+
+func TestListHotelsHandler_Facets(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	t.Run("counts next to the results", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\)`).
+			WithArgs("paris", 20, 0, nil, nil, nil, nil, nil, nil, nil, "fr").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}))
+		mock.ExpectQuery(`GROUP BY GROUPING SETS \(\(city\), \(stars\)\)`).
+			WithArgs("paris", nil, 0, nil, nil, nil, nil, nil, nil, nil, "fr").
+			WillReturnRows(sqlmock.NewRows([]string{"facet", "value", "count"}).
+				AddRow("city", "Paris", 42).
+				AddRow("city", "Lyon", 7).
+				AddRow("stars", "4", 12))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?search=paris&country=fr&facets=city,stars", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		var response struct {
+			Facets map[string][]data.FacetCount `json:"facets"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("could not unmarshal response: %v", err)
+		}
+
+		cities := response.Facets["city"]
+		if len(cities) != 2 || cities[0].Value != "Paris" || cities[0].Count != 42 || cities[1].Value != "Lyon" {
+			t.Errorf("unexpected city facet: %v", cities)
+		}
+		if stars := response.Facets["stars"]; len(stars) != 1 || stars[0].Value != 4.0 {
+			t.Errorf("unexpected stars facet: %v", stars)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("omitted unless requested", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if strings.Contains(rr.Body.String(), `"facets"`) {
+			t.Errorf("expected no facets, got %s", rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	for _, query := range []string{"facets=hotel_name", "facets=city,city"} {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/hotels?"+query, nil)
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+		})
+	}
+}
+
+func TestListHotelsHandler_Relevance(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	now := time.Now()
+
+	t.Run("ranked with highlights", func(t *testing.T) {
+		mock.ExpectQuery(`ORDER BY ts_rank_cd\(fts, \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\)\) DESC, hotel_id ASC`).
+			WithArgs("harbour", 20, 0, nil, nil, nil, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+				"city", "state", "country", "postal_code", "stars", "rating",
+				"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+				"hotel_name_snippet", "description_snippet",
+			}).AddRow(1, 123, "", "Harbour View", "", "", "", "Nice", "", "fr", "", 4, 8.7, 3, true, false, "Rooms facing the old port", nil, nil, now, now, nil,
+				"<mark>Harbour</mark> View", "Rooms facing the old port"))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?search=harbour&sort=relevance", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		var response struct {
+			Hotels []data.Hotel `json:"hotels"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("could not unmarshal response: %v", err)
+		}
+		expected := map[string]string{"hotel_name": "<mark>Harbour</mark> View"}
+		if len(response.Hotels) != 1 || !reflect.DeepEqual(response.Hotels[0].Highlights, expected) {
+			t.Errorf("expected only the matching name to be highlighted, got %s", rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("requires search", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?sort=relevance", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+	})
+}
+
+func TestListHotelsHandler_SearchMode(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	t.Run("prefix", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE \(fts @@ \(to_tsquery\('simple', \$1\) \|\| to_tsquery\('english', \$1\)\) OR \$1 = ''\)`).
+			WithArgs("gra:* & hot:*", 20, 0, nil, nil, nil, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?search=gra+hot&q_mode=prefix", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("websearch", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE \(fts @@ \(websearch_to_tsquery\('simple', \$1\) \|\| websearch_to_tsquery\('english', \$1\)\) OR \$1 = ''\)`).
+			WithArgs(`"grand hotel" -cheap`, 20, 0, nil, nil, nil, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+		req := httptest.NewRequest(http.MethodGet, `/v1/hotels?search=%22grand+hotel%22+-cheap&q_mode=websearch`, nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("fuzzy", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE \(\(fts @@ (.+) OR \$1 <% \(hotel_name \|\| ' ' \|\| coalesce\(city, ''\)\)\) OR \$1 = ''\)`).
+			WithArgs("Hiltn Pris", 20, 0, nil, nil, nil, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels?search=Hiltn+Pris&fuzzy=true", nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{name: "unknown mode", query: "search=grand&q_mode=fuzzy", field: "q_mode"},
+		{name: "fuzzy not a boolean", query: "search=grand&fuzzy=maybe", field: "fuzzy"},
+		{name: "fuzzy prefix", query: "search=gra&q_mode=prefix&fuzzy=true", field: "fuzzy"},
+		{name: "unmatched quote", query: "search=%22grand+hotel&q_mode=websearch", field: "search"},
+		{name: "only exclusions", query: "search=-cheap&q_mode=websearch", field: "search"},
+		{name: "prefix without words", query: "search=%26%21&q_mode=prefix", field: "search"},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/hotels?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+
+			var response struct {
+				Error map[string]string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if _, ok := response.Error[tt.field]; !ok {
+				t.Errorf("expected an error for %s, got %v", tt.field, response.Error)
+			}
+		})
+	}
+}
@@ -58,16 +58,16 @@ func TestIntegrationFullStack(t *testing.T) {
 			method: "GET",
 			url:    "/v1/hotels/123",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 					WithArgs(int64(123)).
 					WillReturnRows(sqlmock.NewRows([]string{
 						"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 						"city", "state", "country", "postal_code", "stars", "rating",
-						"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+						"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
 					}).AddRow(
 						123, "image.jpg", "Test Hotel", "123-456-7890", "test@hotel.com", "123 Main St",
 						"Test City", "Test State", "Test Country", "12345", 5, 4.5,
-						100, true, false, "A wonderful test hotel", time.Now(), time.Now(),
+						100, true, false, "A wonderful test hotel", nil, nil, time.Now(), time.Now(),
 					))
 			},
 			expectedStatus: http.StatusOK,
@@ -90,7 +90,7 @@ func TestIntegrationFullStack(t *testing.T) {
 			method: "GET",
 			url:    "/v1/hotels/999",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 					WithArgs(int64(999)).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -115,15 +115,15 @@ func TestIntegrationFullStack(t *testing.T) {
 				rows := sqlmock.NewRows([]string{
 					"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 					"city", "state", "country", "postal_code", "stars", "rating",
-					"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+					"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
 				}).AddRow(
 					1, 123, "image.jpg", "Test Hotel", "123-456-7890", "test@hotel.com", "123 Main St",
 					"Test City", "Test State", "Test Country", "12345", 5, 4.5,
-					100, true, false, "A wonderful test hotel", time.Now(), time.Now(),
+					100, true, false, "A wonderful test hotel", nil, nil, time.Now(), time.Now(), nil,
 				)
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-					WithArgs("", 20, 0).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+					WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 					WillReturnRows(rows)
 			},
 			expectedStatus: http.StatusOK,
@@ -207,7 +207,7 @@ func TestIntegrationFullStack(t *testing.T) {
 					"booking.com", time.Now(),
 				)
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 					WithArgs(int64(123), "", 20, 0).
 					WillReturnRows(rows)
 			},
@@ -341,7 +341,7 @@ func TestIntegrationErrorHandling(t *testing.T) {
 			name: "database connection error",
 			url:  "/v1/hotels/123",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 					WithArgs(int64(123)).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -369,7 +369,7 @@ func TestIntegrationErrorHandling(t *testing.T) {
 			name: "resource not found",
 			url:  "/v1/hotels/999999",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+				mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 					WithArgs(int64(999999)).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -440,15 +440,15 @@ func TestIntegrationPagination(t *testing.T) {
 				rows := sqlmock.NewRows([]string{
 					"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 					"city", "state", "country", "postal_code", "stars", "rating",
-					"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+					"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
 				}).AddRow(
 					25, 123, "image.jpg", "Test Hotel", "123-456-7890", "test@hotel.com", "123 Main St",
 					"Test City", "Test State", "Test Country", "12345", 5, 4.5,
-					100, true, false, "A wonderful test hotel", time.Now(), time.Now(),
+					100, true, false, "A wonderful test hotel", nil, nil, time.Now(), time.Now(), nil,
 				)
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-					WithArgs("", 10, 0).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+					WithArgs("", 10, 0, nil, nil, nil, nil, nil, nil, nil).
 					WillReturnRows(rows)
 			},
 			expectedStatus: http.StatusOK,
@@ -469,11 +469,12 @@ func TestIntegrationPagination(t *testing.T) {
 				rows := sqlmock.NewRows([]string{
 					"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 					"city", "state", "country", "postal_code", "stars", "rating",
-					"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+					"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+					"hotel_name_snippet", "description_snippet",
 				})
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-					WithArgs("nonexistent", 20, 0).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance, ts_headline(.+) FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+					WithArgs("nonexistent", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 					WillReturnRows(rows)
 			},
 			expectedStatus: http.StatusOK,
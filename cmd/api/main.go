@@ -31,7 +31,11 @@ type config struct {
 		burst   int
 		enabled bool
 	}
-	openAIkey string
+	suggest struct {
+		cacheTTL time.Duration
+	}
+	openAIkey  string
+	adminToken string
 }
 
 type application struct {
@@ -39,6 +43,8 @@ type application struct {
 	logger *slog.Logger
 	models *data.Models
 	wg     sync.WaitGroup
+
+	suggestions *ttlCache[[]*data.Suggestion]
 }
 
 func main() {
@@ -56,7 +62,9 @@ func main() {
 	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests per second")
 	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
+	flag.DurationVar(&cfg.suggest.cacheTTL, "suggest-cache-ttl", time.Minute, "How long /v1/suggest results are cached (0 disables caching)")
 	flag.StringVar(&cfg.openAIkey, "openai-key", "", "OpenAI API key")
+	flag.StringVar(&cfg.adminToken, "admin-token", "", "Bearer token for the /v1/admin endpoints (disabled when empty)")
 
 	flag.Parse()
 
@@ -90,6 +98,8 @@ func main() {
 		config: cfg,
 		logger: logger,
 		models: data.NewModels(db),
+
+		suggestions: newTTLCache[[]*data.Suggestion](cfg.suggest.cacheTTL, maxCachedSuggestions),
 	}
 
 	err = app.serve()
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"crypto/subtle"
 	"expvar"
 	"fmt"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -139,3 +141,22 @@ func (app *application) metrics(next http.Handler) http.Handler {
 		totalProcessingTimeMicroseconds.Add(int64(duration))
 	})
 }
+
+// requireAdmin only lets requests through that carry the -admin-token as a
+// bearer token. Admin routes are hidden entirely when no token is set.
+func (app *application) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.config.adminToken == "" {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(app.config.adminToken)) != 1 {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
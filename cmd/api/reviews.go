@@ -42,7 +42,8 @@ func (app *application) getReviewHandler(w http.ResponseWriter, r *http.Request)
 
 func (app *application) listReviewsHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Search string
+		Search     string
+		SearchMode string
 		data.Filters
 	}
 
@@ -57,17 +58,24 @@ func (app *application) listReviewsHandler(w http.ResponseWriter, r *http.Reques
 	qs := r.URL.Query()
 
 	input.Search = app.readString(qs, "search", "")
+	input.SearchMode = app.readString(qs, "q_mode", data.SearchPlain)
 	input.Filters.Page = app.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
 	input.Filters.Sort = app.readString(qs, "sort", "id")
-	input.Filters.SortSafelist = []string{"id", "hotel_id", "name", "country", "city", "rating", "starts", "-id", "-hotel_id", "-name", "-country", "-city", "-rating", "-starts"}
+	input.Filters.SortSafelist = []string{"id", "hotel_id", "name", "country", "city", "rating", "starts", "relevance", "-id", "-hotel_id", "-name", "-country", "-city", "-rating", "-starts"}
+
+	if input.Search == "" {
+		v.Check(input.Filters.Sort != "relevance", "sort", "relevance requires search")
+	}
+
+	data.ValidateSearch(v, input.Search, input.SearchMode)
 
 	if data.ValidateFilters(v, input.Filters); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	reviews, metadata, err := app.models.Reviews.GetAll(hotelID, input.Search, input.Filters)
+	reviews, metadata, err := app.models.Reviews.GetAll(hotelID, input.Search, input.SearchMode, input.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -262,7 +262,7 @@ func TestListReviewsHandler(t *testing.T) {
 					)
 				}
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 					WithArgs(int64(123), "", 20, 0).
 					WillReturnRows(rows)
 			},
@@ -294,6 +294,7 @@ func TestListReviewsHandler(t *testing.T) {
 				rows := sqlmock.NewRows([]string{
 					"count", "id", "hotel_id", "average_score", "country", "type", "name",
 					"date", "headline", "language", "pros", "cons", "source", "created_at",
+					"pros_snippet", "cons_snippet",
 				})
 
 				// Add one review for search results
@@ -303,9 +304,10 @@ func TestListReviewsHandler(t *testing.T) {
 					review.Country, review.Type, review.Name, review.Date,
 					review.Headline, review.Language, review.Pros, review.Cons,
 					review.Source, review.CreatedAt,
+					review.Pros, review.Cons,
 				)
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at, ts_headline(.+) FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 					WithArgs(int64(123), "excellent", 20, 0).
 					WillReturnRows(rows)
 			},
@@ -335,7 +337,7 @@ func TestListReviewsHandler(t *testing.T) {
 					"date", "headline", "language", "pros", "cons", "source", "created_at",
 				})
 
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 					WithArgs(int64(123), "", 10, 10).
 					WillReturnRows(rows)
 			},
@@ -435,12 +437,103 @@ func TestListReviewsHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:        "sort by relevance",
+			hotelID:     "123",
+			queryParams: "search=breakfast&sort=relevance",
+			setupMock: func() {
+				review := expectedReviews[1]
+				rows := sqlmock.NewRows([]string{
+					"count", "id", "hotel_id", "average_score", "country", "type", "name",
+					"date", "headline", "language", "pros", "cons", "source", "created_at",
+					"pros_snippet", "cons_snippet",
+				}).AddRow(
+					1, review.ID, review.HotelID, review.AverageScore,
+					review.Country, review.Type, review.Name, review.Date,
+					review.Headline, review.Language, review.Pros, review.Cons,
+					review.Source, review.CreatedAt,
+					"Great location, amazing <mark>breakfast</mark>", review.Cons,
+				)
+
+				mock.ExpectQuery(`FROM reviews WHERE (.+) ORDER BY ts_rank_cd\(fts, plainto_tsquery\(review_search_config\(language\), \$2\)\) DESC, id ASC LIMIT \$3 OFFSET \$4`).
+					WithArgs(int64(123), "breakfast", 20, 0).
+					WillReturnRows(rows)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response struct {
+					Reviews []data.Review `json:"reviews"`
+				}
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if len(response.Reviews) != 1 || response.Reviews[0].Highlights["pros"] != "Great location, amazing <mark>breakfast</mark>" {
+					t.Errorf("expected highlighted pros, got %s", rr.Body)
+				}
+			},
+		},
+		{
+			name:        "websearch mode",
+			hotelID:     "123",
+			queryParams: "search=breakfast+or+pool+-noise&q_mode=websearch",
+			setupMock: func() {
+				rows := sqlmock.NewRows([]string{"count"})
+
+				mock.ExpectQuery(`FROM reviews WHERE hotel_id = \$1 AND \(fts @@ websearch_to_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\)`).
+					WithArgs(int64(123), "breakfast or pool -noise", 20, 0).
+					WillReturnRows(rows)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse:  func(t *testing.T, rr *httptest.ResponseRecorder) {},
+		},
+		{
+			name:        "malformed websearch query",
+			hotelID:     "123",
+			queryParams: "search=%22breakfast&q_mode=websearch",
+			setupMock: func() {
+				// No mock setup needed as validation should fail before DB call
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if response["error"] == nil {
+					t.Error("expected error field in response")
+				}
+			},
+		},
+		{
+			name:        "sort by relevance without search",
+			hotelID:     "123",
+			queryParams: "sort=relevance",
+			setupMock: func() {
+				// No mock setup needed as validation should fail before DB call
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if response["error"] == nil {
+					t.Error("expected error field in response")
+				}
+			},
+		},
 		{
 			name:        "database error",
 			hotelID:     "123",
 			queryParams: "",
 			setupMock: func() {
-				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 					WithArgs(int64(123), "", 20, 0).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -717,7 +810,7 @@ func BenchmarkListReviewsHandler(b *testing.B) {
 			"booking.com", time.Now(),
 		)
 
-		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 			WithArgs(int64(123), "", 20, 0).
 			WillReturnRows(rows)
 	}
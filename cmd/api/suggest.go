@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/JLL32/nuitee/internal/data"
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+// maxCachedSuggestions caps how many distinct queries the suggestion cache
+// holds.
+const maxCachedSuggestions = 10_000
+
+// suggestHandler serves search box autocompletion. Results are cached in
+// memory for -suggest-cache-ttl, keyed by the normalised query and limit, as
+// the same few prefixes are typed over and over; hotels synced meanwhile show
+// up once the entry expires.
+func (app *application) suggestHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Q     string
+		Limit int
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Q = app.readString(qs, "q", "")
+	input.Limit = app.readInt(qs, "limit", 10, v)
+
+	if data.ValidateSuggest(v, input.Q, input.Limit); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	key := fmt.Sprintf("%d:%s", input.Limit, data.SuggestKey(input.Q))
+
+	suggestions, found := app.suggestions.Get(key)
+	if !found {
+		var err error
+		suggestions, err = app.models.Hotels.Suggest(input.Q, input.Limit)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		app.suggestions.Set(key, suggestions)
+	}
+
+	headers := make(http.Header)
+	if ttl := int(app.config.suggest.cacheTTL.Seconds()); ttl > 0 {
+		headers.Set("Cache-Control", "public, max-age="+strconv.Itoa(ttl))
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"suggestions": suggestions}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
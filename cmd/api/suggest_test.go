@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+func TestSuggestHandler(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	app.config.suggest.cacheTTL = time.Minute
+
+	mock.ExpectQuery(`WITH matched AS (.+) FROM hotels WHERE fts @@ to_tsquery\('simple', \$1\)`).
+		WithArgs("par:*", "par", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "text", "hotel_id", "city", "country", "count"}).
+			AddRow("city", "Paris", nil, nil, "fr", 310).
+			AddRow("hotel", "Hotel Parisien", 7, "Lyon", "fr", nil))
+
+	// The second request differs only in case and spacing, so it is served
+	// from the cache without querying the database again.
+	for _, q := range []string{"par", "+PAR+"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/suggest?q="+q, nil)
+		rr := httptest.NewRecorder()
+		app.testRoutes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+
+		if got := rr.Header().Get("Cache-Control"); got != "public, max-age=60" {
+			t.Errorf("expected Cache-Control public, max-age=60, got %q", got)
+		}
+
+		var response struct {
+			Suggestions []data.Suggestion `json:"suggestions"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("could not unmarshal response: %v", err)
+		}
+
+		if len(response.Suggestions) != 2 || response.Suggestions[0].Count != 310 || response.Suggestions[1].HotelID != 7 {
+			t.Errorf("unexpected suggestions for %q: %+v", q, response.Suggestions)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	invalid := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{name: "missing q", query: "", field: "q"},
+		{name: "too short", query: "q=p", field: "q"},
+		{name: "limit not an integer", query: "q=par&limit=ten", field: "limit"},
+		{name: "limit too large", query: "q=par&limit=50", field: "limit"},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/suggest?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+
+			var response struct {
+				Error map[string]string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if _, ok := response.Error[tt.field]; !ok {
+				t.Errorf("expected an error for %s, got %v", tt.field, response.Error)
+			}
+		})
+	}
+}
+
+func TestTTLCache(t *testing.T) {
+	c := newTTLCache[int](time.Minute, 2)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a=1, got %v, %v", v, ok)
+	}
+
+	// Adding a third key to a full cache of live entries clears it.
+	c.Set("c", 3)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to be evicted")
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("expected c=3, got %v, %v", v, ok)
+	}
+
+	// Expired entries are not returned.
+	c.entries["d"] = ttlCacheEntry[int]{value: 4, expiresAt: time.Now().Add(-time.Second)}
+	if _, ok := c.Get("d"); ok {
+		t.Error("expected d to have expired")
+	}
+
+	// A zero TTL disables caching.
+	off := newTTLCache[int](0, 2)
+	off.Set("a", 1)
+	if _, ok := off.Get("a"); ok {
+		t.Error("expected nothing to be cached with a zero TTL")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/JLL32/nuitee/internal/data"
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+func (app *application) listQuarantinedHotelsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "next_attempt_at")
+	input.Filters.SortSafelist = []string{
+		"hotel_id", "consecutive_failures", "quarantined_at", "next_attempt_at",
+		"-hotel_id", "-consecutive_failures", "-quarantined_at", "-next_attempt_at",
+	}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	failures, metadata, err := app.models.SyncFailures.GetAllQuarantined(input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"metadata": metadata, "hotels": failures}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// requeueQuarantinedHotelsHandler releases hotels from quarantine so the
+// next sync run retries them. Either hotel_ids or all must be given, so an
+// empty body never requeues everything by accident.
+func (app *application) requeueQuarantinedHotelsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		HotelIDs []int `json:"hotel_ids"`
+		All      bool  `json:"all"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(input.All || len(input.HotelIDs) > 0, "hotel_ids", "must be provided unless all is true")
+	v.Check(!input.All || len(input.HotelIDs) == 0, "hotel_ids", "must not be provided when all is true")
+	v.Check(len(input.HotelIDs) <= 1000, "hotel_ids", "must not contain more than 1000 IDs")
+	for _, id := range input.HotelIDs {
+		v.Check(id > 0, "hotel_ids", "must only contain positive IDs")
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	var requeued []int
+	if input.All {
+		requeued, err = app.models.SyncFailures.RequeueAll()
+	} else {
+		requeued, err = app.models.SyncFailures.Requeue(input.HotelIDs)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"requeued": requeued}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
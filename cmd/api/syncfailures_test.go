@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	app, _, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	tests := []struct {
+		name           string
+		adminToken     string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "admin disabled", adminToken: "", authorization: "Bearer secret", expectedStatus: http.StatusNotFound},
+		{name: "missing token", adminToken: "secret", authorization: "", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", adminToken: "secret", authorization: "Basic secret", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong token", adminToken: "secret", authorization: "Bearer guess", expectedStatus: http.StatusUnauthorized},
+		{name: "valid token", adminToken: "secret", authorization: "Bearer secret", expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app.config.adminToken = tt.adminToken
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/admin/sync/quarantine", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rr := httptest.NewRecorder()
+			app.requireAdmin(ok)(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if rr.Code == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestListQuarantinedHotelsHandler(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+	app.config.adminToken = "secret"
+
+	now := time.Now()
+	columns := []string{
+		"count", "hotel_id", "consecutive_failures", "last_error", "first_failed_at", "last_failed_at", "quarantined_at", "next_attempt_at",
+	}
+
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), (.+) FROM sync_failures WHERE quarantined_at IS NOT NULL ORDER BY next_attempt_at ASC, hotel_id ASC LIMIT \$1 OFFSET \$2`).
+		WithArgs(20, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 404, 6, "fetching hotel data: unexpected status code: 404", now.Add(-48*time.Hour), now, now.Add(-time.Hour), now.Add(time.Hour)))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/sync/quarantine", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	rr := httptest.NewRecorder()
+	app.testRoutes().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Hotels   []data.SyncFailure `json:"hotels"`
+		Metadata data.Metadata      `json:"metadata"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+
+	if len(response.Hotels) != 1 || response.Hotels[0].HotelID != 404 || response.Hotels[0].ConsecutiveFailures != 6 {
+		t.Errorf("unexpected hotels: %+v", response.Hotels)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRequeueQuarantinedHotelsHandler(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+	app.config.adminToken = "secret"
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expected       []int
+	}{
+		{
+			name: "selected hotels",
+			body: `{"hotel_ids": [404, 500]}`,
+			setupMock: func() {
+				mock.ExpectQuery(`UPDATE sync_failures SET (.+) WHERE quarantined_at IS NOT NULL AND hotel_id = ANY\(\$1\)`).
+					WithArgs("{404,500}").
+					WillReturnRows(sqlmock.NewRows([]string{"hotel_id"}).AddRow(404))
+			},
+			expectedStatus: http.StatusOK,
+			expected:       []int{404},
+		},
+		{
+			name: "all hotels",
+			body: `{"all": true}`,
+			setupMock: func() {
+				mock.ExpectQuery(`UPDATE sync_failures SET (.+) WHERE quarantined_at IS NOT NULL RETURNING hotel_id`).
+					WillReturnRows(sqlmock.NewRows([]string{"hotel_id"}).AddRow(404).AddRow(500))
+			},
+			expectedStatus: http.StatusOK,
+			expected:       []int{404, 500},
+		},
+		{name: "nothing selected", body: `{}`, setupMock: func() {}, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "ids and all", body: `{"all": true, "hotel_ids": [1]}`, setupMock: func() {}, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "invalid id", body: `{"hotel_ids": [0]}`, setupMock: func() {}, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "malformed body", body: `{"hotel_ids": "404"}`, setupMock: func() {}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/admin/sync/quarantine/requeue", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+
+			rr := httptest.NewRecorder()
+			app.testRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+
+			if tt.expected != nil {
+				var response struct {
+					Requeued []int `json:"requeued"`
+				}
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+				if len(response.Requeued) != len(tt.expected) {
+					t.Errorf("expected %v to be requeued, got %v", tt.expected, response.Requeued)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JLL32/nuitee/internal/data"
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+func (app *application) listSyncRunsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-id")
+	input.Filters.SortSafelist = []string{"id", "started_at", "failed", "-id", "-started_at", "-failed"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	runs, metadata, err := app.models.SyncRuns.GetAll(input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"metadata": metadata, "runs": runs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getSyncRunHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Outcome string
+		data.Filters
+	}
+
+	id, err := app.readIDParam(r, "id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Outcome = app.readString(qs, "outcome", "")
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 100, v)
+	input.Filters.Sort = app.readString(qs, "sort", "id")
+	input.Filters.SortSafelist = []string{"id", "hotel_id", "duration_ms", "-id", "-hotel_id", "-duration_ms"}
+
+	data.ValidateSyncOutcome(v, input.Outcome)
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	run, err := app.models.SyncRuns.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	items, metadata, err := app.models.SyncRuns.GetItems(run.ID, input.Outcome, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"run": run, "metadata": metadata, "items": items}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/data"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestListSyncRunsHandler(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		setupMock      func()
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "default parameters",
+			queryParams: "",
+			setupMock: func() {
+				rows := sqlmock.NewRows([]string{
+					"count", "id", "tier", "started_at", "finished_at", "hotels_total", "succeeded", "failed", "skipped", "reviews_inserted", "reviews_updated", "reviews_removed",
+				}).
+					AddRow(2, 2, "default", time.Now(), nil, 10, 0, 0, 0, 0, 0, 0).
+					AddRow(2, 1, "default", time.Now(), time.Now(), 10, 9, 1, 0, 120, 40, 3)
+
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), (.+) FROM sync_runs ORDER BY id DESC, id DESC LIMIT \$1 OFFSET \$2`).
+					WithArgs(20, 0).
+					WillReturnRows(rows)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response struct {
+					Runs     []data.SyncRun `json:"runs"`
+					Metadata data.Metadata  `json:"metadata"`
+				}
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if len(response.Runs) != 2 {
+					t.Errorf("expected 2 runs, got %d", len(response.Runs))
+				}
+
+				if response.Runs[1].Failed != 1 {
+					t.Errorf("expected the older run to have 1 failure, got %d", response.Runs[1].Failed)
+				}
+
+				if response.Metadata.TotalRecords != 2 {
+					t.Errorf("expected TotalRecords to be 2, got %d", response.Metadata.TotalRecords)
+				}
+			},
+		},
+		{
+			name:           "invalid sort",
+			queryParams:    "sort=hotel_name",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response map[string]map[string]string
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if response["error"]["sort"] == "" {
+					t.Error("expected a sort validation error")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req, err := http.NewRequest("GET", "/v1/sync/runs?"+tt.queryParams, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+
+			router := httprouter.New()
+			router.HandlerFunc(http.MethodGet, "/v1/sync/runs", app.listSyncRunsHandler)
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			tt.checkResponse(t, rr)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetSyncRunHandler(t *testing.T) {
+	app, mock, cleanup := newTestApplication(t)
+	defer cleanup()
+
+	runColumns := []string{
+		"id", "tier", "started_at", "finished_at", "hotels_total", "succeeded", "failed", "skipped", "reviews_inserted", "reviews_updated", "reviews_removed",
+	}
+
+	itemColumns := []string{
+		"count", "id", "run_id", "hotel_id", "outcome", "error", "reviews_inserted", "reviews_updated", "reviews_removed", "duration_ms", "created_at",
+	}
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func()
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "run with failed items",
+			url:  "/v1/sync/runs/7?outcome=failed",
+			setupMock: func() {
+				mock.ExpectQuery(`SELECT (.+) FROM sync_runs WHERE id = \$1`).
+					WithArgs(int64(7)).
+					WillReturnRows(sqlmock.NewRows(runColumns).AddRow(7, "popular", time.Now(), time.Now(), 2, 1, 1, 0, 5, 0, 0))
+
+				mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), (.+) FROM sync_run_items WHERE run_id = \$1`).
+					WithArgs(int64(7), "failed", 100, 0).
+					WillReturnRows(sqlmock.NewRows(itemColumns).
+						AddRow(1, 2, 7, 123, "failed", "fetching hotel data: unexpected status code: 404", 0, 0, 0, 80, time.Now()))
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response struct {
+					Run   data.SyncRun       `json:"run"`
+					Items []data.SyncRunItem `json:"items"`
+				}
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if response.Run.ID != 7 {
+					t.Errorf("expected run ID 7, got %d", response.Run.ID)
+				}
+
+				if len(response.Items) != 1 || response.Items[0].HotelID != 123 || response.Items[0].Error == "" {
+					t.Errorf("unexpected items: %+v", response.Items)
+				}
+			},
+		},
+		{
+			name: "run not found",
+			url:  "/v1/sync/runs/99",
+			setupMock: func() {
+				mock.ExpectQuery(`SELECT (.+) FROM sync_runs WHERE id = \$1`).
+					WithArgs(int64(99)).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			checkResponse:  func(t *testing.T, rr *httptest.ResponseRecorder) {},
+		},
+		{
+			name:           "invalid ID",
+			url:            "/v1/sync/runs/abc",
+			setupMock:      func() {},
+			expectedStatus: http.StatusNotFound,
+			checkResponse:  func(t *testing.T, rr *httptest.ResponseRecorder) {},
+		},
+		{
+			name:           "invalid outcome",
+			url:            "/v1/sync/runs/7?outcome=exploded",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				var response map[string]map[string]string
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("could not unmarshal response: %v", err)
+				}
+
+				if response["error"]["outcome"] == "" {
+					t.Error("expected an outcome validation error")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+
+			router := httprouter.New()
+			router.HandlerFunc(http.MethodGet, "/v1/sync/runs/:id", app.getSyncRunHandler)
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+
+			tt.checkResponse(t, rr)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -49,6 +49,8 @@ func newTestApplication(t *testing.T) (*application, sqlmock.Sqlmock, func()) {
 		config: cfg,
 		logger: logger,
 		models: data.NewModels(db),
+
+		suggestions: newTTLCache[[]*data.Suggestion](time.Minute, maxCachedSuggestions),
 	}
 
 	return app, mock, func() {
@@ -93,6 +95,8 @@ func newTestApplicationForBenchmark(b *testing.B) (*application, sqlmock.Sqlmock
 		config: cfg,
 		logger: logger,
 		models: data.NewModels(db),
+
+		suggestions: newTTLCache[[]*data.Suggestion](time.Minute, maxCachedSuggestions),
 	}
 
 	return app, mock, func() {
@@ -111,10 +115,19 @@ func (app *application) testRoutes() http.Handler {
 
 	router.HandlerFunc(http.MethodGet, "/v1/hotels", app.listHotelsHandler)
 	router.HandlerFunc(http.MethodGet, "/v1/hotels/:hotelID", app.getHotelHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/hotels/:hotelID/history", app.listHotelHistoryHandler)
 
 	router.HandlerFunc(http.MethodGet, "/v1/hotels/:hotelID/reviews", app.listReviewsHandler)
 	router.HandlerFunc(http.MethodGet, "/v1/hotels/:hotelID/reviews/:reviewID", app.getReviewHandler)
 	router.HandlerFunc(http.MethodGet, "/v1/hotels/:hotelID/reviews/:reviewID/summary", app.getReviewSummaryHandler)
 
+	router.HandlerFunc(http.MethodGet, "/v1/suggest", app.suggestHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v1/sync/runs", app.listSyncRunsHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/sync/runs/:id", app.getSyncRunHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v1/admin/sync/quarantine", app.requireAdmin(app.listQuarantinedHotelsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/admin/sync/quarantine/requeue", app.requireAdmin(app.requeueQuarantinedHotelsHandler))
+
 	return app.recoverPanic(app.rateLimit(router))
 }
\ No newline at end of file
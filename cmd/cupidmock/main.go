@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"flag"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/cupidmock"
+)
+
+// defaultFixtures are served when -fixtures is not set.
+//
+//go:embed fixtures
+var defaultFixtures embed.FS
+
+func main() {
+	var cfg cupidmock.Config
+	var addr, fixturesDir string
+
+	flag.StringVar(&addr, "addr", ":8081", "Address to listen on")
+	flag.StringVar(&fixturesDir, "fixtures", "", "Fixture directory with property/<id>.json and reviews/<id>.json (built-in fixtures when empty)")
+	flag.StringVar(&cfg.APIKey, "api-key", "", "Require this x-api-key header (any key accepted when empty)")
+	flag.DurationVar(&cfg.Latency, "latency", 0, "Delay added to every response")
+	flag.Float64Var(&cfg.RateLimitRate, "rate-limit-rate", 0, "Share of requests answered with 429 (0-1)")
+	flag.DurationVar(&cfg.RetryAfter, "retry-after", time.Second, "Retry-After sent with 429 responses")
+	flag.Float64Var(&cfg.ErrorRate, "error-rate", 0, "Share of requests answered with 500 (0-1)")
+	flag.Float64Var(&cfg.MalformedRate, "malformed-rate", 0, "Share of responses with a truncated JSON body (0-1)")
+	flag.Uint64Var(&cfg.Seed, "seed", 0, "Seed for injected faults, for reproducible runs (random when 0)")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	var fixtures fs.FS
+	if fixturesDir != "" {
+		fixtures = os.DirFS(fixturesDir)
+	} else {
+		fixtures, _ = fs.Sub(defaultFixtures, "fixtures")
+	}
+
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      cupidmock.New(fixtures, cfg),
+		IdleTimeout:  time.Minute,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10*time.Second + cfg.Latency,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		<-quit
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		srv.Shutdown(ctx)
+	}()
+
+	logger.Info("starting cupid mock", "addr", addr, "fixtures", fixturesDir,
+		"latency", cfg.Latency.String(),
+		"rate_limit_rate", cfg.RateLimitRate,
+		"error_rate", cfg.ErrorRate,
+		"malformed_rate", cfg.MalformedRate,
+	)
+
+	err := srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/data"
+	"golang.org/x/time/rate"
+)
+
+type statusError struct {
+	statusCode int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.statusCode)
+}
+
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// backoff returns the delay before retry number attempt (starting at 1),
+// using exponential growth capped at maxDelay with full jitter.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.maxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := p.baseDelay << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return rand.N(ceiling + 1)
+}
+
+// delay returns how long to wait before retry number attempt. A Retry-After
+// from the server is honoured up to maxDelay: scheduled runs have no deadline,
+// so a server asking for a day would otherwise stall a worker for a day.
+func (p retryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, p.maxDelay)
+	}
+
+	return p.backoff(attempt)
+}
+
+type cupidClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+	retry   retryPolicy
+	limiter *rate.Limiter
+	logger  *slog.Logger
+	sleep   func(context.Context, time.Duration) error
+
+	requests      atomic.Int64
+	limiterWait   atomic.Int64
+	limiterDelays atomic.Int64
+}
+
+// newCupidClient returns a client for the Cupid API. A nil limiter disables
+// client-side rate limiting; otherwise every request, including retries,
+// takes a token from it, so a single limiter shared by all sync workers caps
+// the total outbound request rate.
+func newCupidClient(baseURL, apiKey string, retry retryPolicy, limiter *rate.Limiter, logger *slog.Logger) *cupidClient {
+	return &cupidClient{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		retry:   retry,
+		limiter: limiter,
+		logger:  logger,
+		sleep:   sleepContext,
+	}
+}
+
+type clientMetrics struct {
+	Requests      int64
+	LimiterWait   time.Duration
+	LimiterDelays int64
+}
+
+func (c *cupidClient) metrics() clientMetrics {
+	return clientMetrics{
+		Requests:      c.requests.Load(),
+		LimiterWait:   time.Duration(c.limiterWait.Load()),
+		LimiterDelays: c.limiterDelays.Load(),
+	}
+}
+
+func (m clientMetrics) sub(other clientMetrics) clientMetrics {
+	return clientMetrics{
+		Requests:      m.Requests - other.Requests,
+		LimiterWait:   m.LimiterWait - other.LimiterWait,
+		LimiterDelays: m.LimiterDelays - other.LimiterDelays,
+	}
+}
+
+// waitForToken blocks until the rate limiter admits another request and
+// records how long that took.
+func (c *cupidClient) waitForToken(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+
+	start := time.Now()
+
+	err := c.limiter.Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	waited := time.Since(start)
+	c.limiterWait.Add(int64(waited))
+	if waited > time.Millisecond {
+		c.limiterDelays.Add(1)
+	}
+
+	return nil
+}
+
+// cupidProperty is the Cupid property payload. The photo, facility, room and
+// policy lists decode straight into data.Hotel; check-in and check-out times
+// come as a separate object and are turned into policies.
+type cupidProperty struct {
+	data.Hotel
+	Checkin *cupidCheckin `json:"checkin"`
+}
+
+type cupidCheckin struct {
+	CheckinStart        string `json:"checkin_start"`
+	CheckinEnd          string `json:"checkin_end"`
+	Checkout            string `json:"checkout"`
+	SpecialInstructions string `json:"special_instructions"`
+}
+
+// policies returns the check-in rules as policies, leaving out the ones
+// Cupid has no value for.
+func (c *cupidCheckin) policies() []data.HotelPolicy {
+	var policies []data.HotelPolicy
+
+	switch {
+	case c.CheckinStart != "" && c.CheckinEnd != "":
+		policies = append(policies, data.HotelPolicy{PolicyType: "checkin", Name: "Check-in", Description: fmt.Sprintf("From %s to %s", c.CheckinStart, c.CheckinEnd)})
+	case c.CheckinStart != "":
+		policies = append(policies, data.HotelPolicy{PolicyType: "checkin", Name: "Check-in", Description: "From " + c.CheckinStart})
+	}
+
+	if c.Checkout != "" {
+		policies = append(policies, data.HotelPolicy{PolicyType: "checkout", Name: "Check-out", Description: "Until " + c.Checkout})
+	}
+
+	if c.SpecialInstructions != "" {
+		policies = append(policies, data.HotelPolicy{PolicyType: "checkin_instructions", Name: "Check-in instructions", Description: c.SpecialInstructions})
+	}
+
+	return policies
+}
+
+func (c *cupidClient) getHotel(ctx context.Context, id string) (*data.Hotel, error) {
+	var property cupidProperty
+
+	err := c.getJSON(ctx, fmt.Sprintf("/v3.0/property/%s", id), &property)
+	if err != nil {
+		return nil, err
+	}
+
+	hotel := property.Hotel
+	if property.Checkin != nil {
+		hotel.Policies = append(property.Checkin.policies(), hotel.Policies...)
+	}
+
+	return &hotel, nil
+}
+
+func (c *cupidClient) getReviews(ctx context.Context, id string, count int) ([]data.Review, error) {
+	var reviews []data.Review
+
+	err := c.getJSON(ctx, fmt.Sprintf("/v3.0/property/reviews/%s/%d", id, count), &reviews)
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// getJSON fetches path and decodes the response into output, retrying
+// retryable failures according to the client's retry policy.
+func (c *cupidClient) getJSON(ctx context.Context, path string, output any) error {
+	url := c.baseURL + path
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = c.fetchJson(ctx, url, output)
+		if err == nil || !isRetryable(err) || attempt >= c.retry.maxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		var retryAfter time.Duration
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.retryAfter
+		}
+		delay := c.retry.delay(attempt, retryAfter)
+
+		c.logger.Debug("retrying cupid request", "url", url, "attempt", attempt, "delay", delay.String(), "error", err.Error())
+
+		if err := c.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *cupidClient) fetchJson(ctx context.Context, url string, output any) error {
+	err := c.waitForToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.requests.Add(1)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("x-api-key", c.apiKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Drain a little of the body so the connection can be reused.
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+		return &statusError{
+			statusCode: resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+
+	err = decoder.Decode(output)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// isRetryable reports whether err is worth another attempt: transport
+// failures and timeouts, 408, 429 and 5xx responses. Everything else,
+// including 401, 404 and malformed payloads, is treated as permanent.
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.statusCode == http.StatusRequestTimeout,
+			statusErr.statusCode == http.StatusTooManyRequests,
+			statusErr.statusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseRetryAfter understands both forms of the Retry-After header: a number
+// of seconds or an HTTP date. It returns zero when the header is absent or
+// unparseable.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/cupidmock"
+	"golang.org/x/time/rate"
+)
+
+// fakeCupid is an httptest stand-in for the Cupid API that replays a scripted
+// sequence of responses, one per request.
+type fakeCupid struct {
+	server    *httptest.Server
+	responses []func(w http.ResponseWriter)
+	requests  atomic.Int32
+	apiKeys   sync.Map
+}
+
+func newFakeCupid(t *testing.T, responses ...func(w http.ResponseWriter)) *fakeCupid {
+	t.Helper()
+
+	f := &fakeCupid{responses: responses}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(f.requests.Add(1)) - 1
+		f.apiKeys.Store(r.Header.Get("x-api-key"), true)
+
+		if n >= len(f.responses) {
+			n = len(f.responses) - 1
+		}
+		f.responses[n](w)
+	}))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func respondJSON(body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}
+}
+
+func respondStatus(status int, headers ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(status)
+	}
+}
+
+func newTestCupidClient(url string, policy retryPolicy) (*cupidClient, *[]time.Duration) {
+	var delays []time.Duration
+
+	client := newCupidClient(url, "test-key", policy, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+
+	return client, &delays
+}
+
+var testRetryPolicy = retryPolicy{
+	maxAttempts: 3,
+	baseDelay:   100 * time.Millisecond,
+	maxDelay:    time.Second,
+}
+
+func TestCupidClient_GetHotel(t *testing.T) {
+	cupid := newFakeCupid(t, respondJSON(`{"hotel_id": 123, "hotel_name": "Test Hotel"}`))
+	client, delays := newTestCupidClient(cupid.server.URL, testRetryPolicy)
+
+	hotel, err := client.getHotel(context.Background(), "123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if hotel.HotelID != 123 || hotel.HotelName != "Test Hotel" {
+		t.Errorf("unexpected hotel: %+v", hotel)
+	}
+
+	if got := cupid.requests.Load(); got != 1 {
+		t.Errorf("expected 1 request, got %d", got)
+	}
+
+	if len(*delays) != 0 {
+		t.Errorf("expected no retries, got delays %v", *delays)
+	}
+
+	if _, ok := cupid.apiKeys.Load("test-key"); !ok {
+		t.Error("expected the x-api-key header to be sent")
+	}
+}
+
+func TestCupidClient_Retries(t *testing.T) {
+	tests := []struct {
+		name             string
+		responses        []func(w http.ResponseWriter)
+		expectError      bool
+		expectedRequests int32
+		checkDelays      func(*testing.T, []time.Duration)
+	}{
+		{
+			name:             "5xx then success",
+			responses:        []func(w http.ResponseWriter){respondStatus(http.StatusServiceUnavailable), respondJSON(`[]`)},
+			expectError:      false,
+			expectedRequests: 2,
+		},
+		{
+			name:             "429 honors Retry-After seconds",
+			responses:        []func(w http.ResponseWriter){respondStatus(http.StatusTooManyRequests, "Retry-After", "1"), respondJSON(`[]`)},
+			expectError:      false,
+			expectedRequests: 2,
+			checkDelays: func(t *testing.T, delays []time.Duration) {
+				if len(delays) != 1 || delays[0] != time.Second {
+					t.Errorf("expected a single 1s delay, got %v", delays)
+				}
+			},
+		},
+		{
+			name:             "429 caps Retry-After at the max delay",
+			responses:        []func(w http.ResponseWriter){respondStatus(http.StatusTooManyRequests, "Retry-After", "86400"), respondJSON(`[]`)},
+			expectError:      false,
+			expectedRequests: 2,
+			checkDelays: func(t *testing.T, delays []time.Duration) {
+				if len(delays) != 1 || delays[0] != testRetryPolicy.maxDelay {
+					t.Errorf("expected a single %v delay, got %v", testRetryPolicy.maxDelay, delays)
+				}
+			},
+		},
+		{
+			name:             "429 without Retry-After backs off",
+			responses:        []func(w http.ResponseWriter){respondStatus(http.StatusTooManyRequests), respondJSON(`[]`)},
+			expectError:      false,
+			expectedRequests: 2,
+			checkDelays: func(t *testing.T, delays []time.Duration) {
+				if len(delays) != 1 || delays[0] > testRetryPolicy.baseDelay {
+					t.Errorf("expected a single delay of at most %v, got %v", testRetryPolicy.baseDelay, delays)
+				}
+			},
+		},
+		{
+			name:             "persistent 500 gives up after max attempts",
+			responses:        []func(w http.ResponseWriter){respondStatus(http.StatusInternalServerError)},
+			expectError:      true,
+			expectedRequests: 3,
+			checkDelays: func(t *testing.T, delays []time.Duration) {
+				if len(delays) != 2 {
+					t.Errorf("expected 2 delays, got %v", delays)
+				}
+			},
+		},
+		{
+			name:             "404 is permanent",
+			responses:        []func(w http.ResponseWriter){respondStatus(http.StatusNotFound)},
+			expectError:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "401 is permanent",
+			responses:        []func(w http.ResponseWriter){respondStatus(http.StatusUnauthorized)},
+			expectError:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "malformed JSON is permanent",
+			responses:        []func(w http.ResponseWriter){respondJSON(`[{"id": `)},
+			expectError:      true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cupid := newFakeCupid(t, tt.responses...)
+			client, delays := newTestCupidClient(cupid.server.URL, testRetryPolicy)
+
+			_, err := client.getReviews(context.Background(), "123", 10)
+
+			if tt.expectError && err == nil {
+				t.Error("expected error, but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if got := cupid.requests.Load(); got != tt.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tt.expectedRequests, got)
+			}
+
+			if tt.checkDelays != nil {
+				tt.checkDelays(t, *delays)
+			}
+		})
+	}
+}
+
+func TestCupidClient_RetriesTimeouts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		io.WriteString(w, `{"hotel_id": 1}`)
+	}))
+	defer server.Close()
+
+	client, _ := newTestCupidClient(server.URL, testRetryPolicy)
+	client.http.Timeout = 50 * time.Millisecond
+
+	_, err := client.getHotel(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected the timed out request to be retried, got %d requests", got)
+	}
+}
+
+func TestCupidClient_ContextCancelled(t *testing.T) {
+	cupid := newFakeCupid(t, respondStatus(http.StatusServiceUnavailable))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, _ := newTestCupidClient(cupid.server.URL, testRetryPolicy)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	_, err := client.getHotel(ctx, "1")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	if got := cupid.requests.Load(); got != 1 {
+		t.Errorf("expected no further requests after cancellation, got %d", got)
+	}
+}
+
+func TestCupidClient_RateLimit(t *testing.T) {
+	cupid := newFakeCupid(t, respondJSON(`{"hotel_id": 1}`))
+	client, _ := newTestCupidClient(cupid.server.URL, testRetryPolicy)
+	client.limiter = rate.NewLimiter(rate.Every(20*time.Millisecond), 1)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.getHotel(context.Background(), "1"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// One token is available up front, the other four are spaced 20ms apart.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected requests to be spread over at least 80ms, took %v", elapsed)
+	}
+
+	metrics := client.metrics()
+	if metrics.Requests != 5 {
+		t.Errorf("expected 5 requests, got %d", metrics.Requests)
+	}
+	if metrics.LimiterDelays < 4 {
+		t.Errorf("expected at least 4 delayed requests, got %d", metrics.LimiterDelays)
+	}
+	if metrics.LimiterWait < 80*time.Millisecond {
+		t.Errorf("expected at least 80ms of total wait, got %v", metrics.LimiterWait)
+	}
+}
+
+func TestCupidClient_RateLimitContextDeadline(t *testing.T) {
+	cupid := newFakeCupid(t, respondJSON(`{"hotel_id": 1}`))
+	client, _ := newTestCupidClient(cupid.server.URL, testRetryPolicy)
+	client.limiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+	client.limiter.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.getHotel(ctx, "1")
+	if err == nil {
+		t.Fatal("expected error, but got none")
+	}
+
+	if got := cupid.requests.Load(); got != 0 {
+		t.Errorf("expected no requests to be sent, got %d", got)
+	}
+}
+
+func TestClientMetrics_Sub(t *testing.T) {
+	before := clientMetrics{Requests: 10, LimiterWait: time.Second, LimiterDelays: 3}
+	after := clientMetrics{Requests: 25, LimiterWait: 4 * time.Second, LimiterDelays: 7}
+
+	got := after.sub(before)
+	expected := clientMetrics{Requests: 15, LimiterWait: 3 * time.Second, LimiterDelays: 4}
+
+	if got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "429", err: &statusError{statusCode: 429}, expected: true},
+		{name: "500", err: &statusError{statusCode: 500}, expected: true},
+		{name: "503", err: &statusError{statusCode: 503}, expected: true},
+		{name: "408", err: &statusError{statusCode: 408}, expected: true},
+		{name: "400", err: &statusError{statusCode: 400}, expected: false},
+		{name: "401", err: &statusError{statusCode: 401}, expected: false},
+		{name: "404", err: &statusError{statusCode: 404}, expected: false},
+		{name: "wrapped 503", err: fmt.Errorf("fetching: %w", &statusError{statusCode: 503}), expected: true},
+		{name: "context cancelled", err: context.Canceled, expected: false},
+		{name: "plain error", err: errors.New("boom"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	for attempt := 1; attempt <= 40; attempt++ {
+		ceiling := min(policy.baseDelay<<min(attempt-1, 20), policy.maxDelay)
+
+		for range 50 {
+			d := policy.backoff(attempt)
+			if d < 0 || d > ceiling {
+				t.Fatalf("attempt %d: delay %v outside [0, %v]", attempt, d, ceiling)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "empty", value: "", expected: 0},
+		{name: "seconds", value: "30", expected: 30 * time.Second},
+		{name: "negative seconds", value: "-5", expected: 0},
+		{name: "http date", value: now.Add(90 * time.Second).Format(http.TimeFormat), expected: 90 * time.Second},
+		{name: "date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0},
+		{name: "garbage", value: "soon", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: 30 * time.Second}
+
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		expected   time.Duration
+	}{
+		{name: "retry after", retryAfter: 10 * time.Second, expected: 10 * time.Second},
+		{name: "retry after at the cap", retryAfter: 30 * time.Second, expected: 30 * time.Second},
+		{name: "retry after beyond the cap", retryAfter: 86400 * time.Second, expected: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.delay(1, tt.retryAfter); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if d := policy.delay(1, 0); d < 0 || d > policy.baseDelay {
+		t.Errorf("expected a backoff delay without Retry-After, got %v", d)
+	}
+}
+
+func BenchmarkRetryPolicy_Backoff(b *testing.B) {
+	policy := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: 30 * time.Second}
+
+	for i := 0; b.Loop(); i++ {
+		policy.backoff(i%10 + 1)
+	}
+}
+
+func TestCupidClient_AgainstMock(t *testing.T) {
+	fixtures := fstest.MapFS{
+		"property/123.json": {Data: []byte(`{"hotel_id": 123, "hotel_name": "Test Hotel", "review_count": 2}`)},
+		"reviews/123.json":  {Data: []byte(`[{"name": "Emma", "average_score": 9}, {"name": "Julien", "average_score": 8}]`)},
+	}
+
+	// Half of the requests are rate limited, so the client has to retry.
+	mock := cupidmock.New(fixtures, cupidmock.Config{APIKey: "test-key", RateLimitRate: 0.5, RetryAfter: time.Second, Seed: 1})
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	client, _ := newTestCupidClient(server.URL, retryPolicy{maxAttempts: 10, baseDelay: time.Millisecond, maxDelay: time.Millisecond})
+
+	hotel, err := client.getHotel(context.Background(), "123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hotel.HotelID != 123 || hotel.HotelName != "Test Hotel" {
+		t.Errorf("unexpected hotel: %+v", hotel)
+	}
+
+	reviews, err := client.getReviews(context.Background(), "123", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].Name != "Emma" {
+		t.Errorf("unexpected reviews: %+v", reviews)
+	}
+
+	_, err = client.getHotel(context.Background(), "404")
+	if err == nil {
+		t.Error("expected an error for a hotel without fixtures")
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+type reviewChange struct {
+	review  data.Review
+	changes []data.FieldChange
+}
+
+// hotelDiff describes what a sync would write for one hotel.
+type hotelDiff struct {
+	hotelID          int
+	hotelName        string
+	isNew            bool
+	changes          []data.FieldChange
+	changedSections  []string
+	newReviews       []data.Review
+	changedReviews   []reviewChange
+	removedReviews   []data.Review
+	unchangedReviews int
+}
+
+func (d hotelDiff) empty() bool {
+	return !d.isNew && len(d.changes) == 0 && len(d.changedSections) == 0 && len(d.newReviews) == 0 && len(d.changedReviews) == 0 && len(d.removedReviews) == 0
+}
+
+// previewHotel compares a fetched hotel and its reviews with what is stored
+// without writing anything. Stored reviews missing from reviews are only
+// reported as removed when reviews is the full history.
+func (app *application) previewHotel(hotel *data.Hotel, reviews []data.Review, full bool) (hotelDiff, error) {
+	diff := hotelDiff{hotelID: hotel.HotelID, hotelName: hotel.HotelName}
+
+	stored, err := app.models.Hotels.Get(int64(hotel.HotelID))
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		diff.isNew = true
+	case err != nil:
+		return hotelDiff{}, err
+	default:
+		diff.changes = stored.Diff(hotel)
+
+		diff.changedSections, err = app.models.HotelDetails.Diff(hotel)
+		if err != nil {
+			return hotelDiff{}, err
+		}
+	}
+
+	existing := map[string]*data.Review{}
+	if !diff.isNew {
+		storedReviews, err := app.models.Reviews.GetAllForHotel(hotel.HotelID)
+		if err != nil {
+			return hotelDiff{}, err
+		}
+		for _, review := range storedReviews {
+			existing[review.Key()] = review
+		}
+	}
+
+	fetched := make(map[string]bool, len(reviews))
+	for _, review := range reviews {
+		fetched[review.Key()] = true
+
+		old, ok := existing[review.Key()]
+		if !ok {
+			diff.newReviews = append(diff.newReviews, review)
+			continue
+		}
+
+		if changes := old.Diff(&review); len(changes) > 0 {
+			diff.changedReviews = append(diff.changedReviews, reviewChange{review: review, changes: changes})
+		} else {
+			diff.unchangedReviews++
+		}
+	}
+
+	// Mirrors syncHotel, which leaves stored reviews alone when Cupid
+	// returns none.
+	if full && len(reviews) > 0 {
+		for key, review := range existing {
+			if !fetched[key] {
+				diff.removedReviews = append(diff.removedReviews, *review)
+			}
+		}
+		slices.SortFunc(diff.removedReviews, func(a, b data.Review) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+	}
+
+	return diff, nil
+}
+
+// format renders the diff in a unified-diff-like layout: + for additions,
+// ~ for modifications and - for removals.
+func (d hotelDiff) format() []byte {
+	var buf bytes.Buffer
+
+	switch {
+	case d.isNew:
+		fmt.Fprintf(&buf, "+ hotel %d %q\n", d.hotelID, d.hotelName)
+	case d.empty():
+		fmt.Fprintf(&buf, "= hotel %d %q unchanged (%d reviews)\n", d.hotelID, d.hotelName, d.unchangedReviews)
+		return buf.Bytes()
+	default:
+		fmt.Fprintf(&buf, "~ hotel %d %q\n", d.hotelID, d.hotelName)
+	}
+
+	for _, c := range d.changes {
+		fmt.Fprintf(&buf, "    %s: %q -> %q\n", c.Field, c.OldValue, c.NewValue)
+	}
+
+	for _, section := range d.changedSections {
+		fmt.Fprintf(&buf, "    %s replaced\n", section)
+	}
+
+	for _, r := range d.newReviews {
+		fmt.Fprintf(&buf, "  + review %q by %q on %s\n", r.Headline, r.Name, r.Date)
+	}
+
+	for _, rc := range d.changedReviews {
+		fmt.Fprintf(&buf, "  ~ review %q by %q on %s\n", rc.review.Headline, rc.review.Name, rc.review.Date)
+		for _, c := range rc.changes {
+			fmt.Fprintf(&buf, "      %s: %q -> %q\n", c.Field, c.OldValue, c.NewValue)
+		}
+	}
+
+	for _, r := range d.removedReviews {
+		fmt.Fprintf(&buf, "  - review %q by %q on %s\n", r.Headline, r.Name, r.Date)
+	}
+
+	fmt.Fprintf(&buf, "  reviews: %d new, %d changed, %d removed, %d unchanged\n", len(d.newReviews), len(d.changedReviews), len(d.removedReviews), d.unchangedReviews)
+
+	return buf.Bytes()
+}
+
+func (app *application) printDiff(d hotelDiff) {
+	app.outMu.Lock()
+	defer app.outMu.Unlock()
+
+	app.out.Write(d.format())
+}
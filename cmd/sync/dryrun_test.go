@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+var hotelColumns = []string{
+	"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+	"city", "state", "country", "postal_code", "stars", "rating",
+	"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
+}
+
+var reviewColumns = []string{
+	"id", "hotel_id", "average_score", "country", "type", "name",
+	"date", "headline", "language", "pros", "cons", "source", "created_at",
+}
+
+func TestRunSync_DryRun(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.0/property/123":
+			io.WriteString(w, `{"hotel_id": 123, "hotel_name": "Grand Hotel", "stars": 5, "rating": 4.5}`)
+		case "/v3.0/property/reviews/123/1000000":
+			io.WriteString(w, `[
+				{"name": "Jane", "date": "2024-01-15 10:00:00", "headline": "Lovely", "pros": "Quiet rooms", "average_score": 9},
+				{"name": "John", "date": "2024-02-01 08:30:00", "headline": "Fine", "pros": "Breakfast", "average_score": 7},
+				{"name": "Ann", "date": "2024-03-01 12:00:00", "headline": "Great", "pros": "View", "average_score": 10}
+			]`)
+		case "/v3.0/property/456":
+			io.WriteString(w, `{"hotel_id": 456, "hotel_name": "New Inn"}`)
+		case "/v3.0/property/reviews/456/1000000":
+			io.WriteString(w, `[{"name": "Bob", "date": "2024-01-01", "headline": "Ok"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cupid.Close()
+
+	app, mock := newTestApplication(t, cupid.URL)
+	app.config.dryRun = true
+
+	var out bytes.Buffer
+	app.out = &out
+
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1`).
+		WithArgs(int64(123)).
+		WillReturnRows(sqlmock.NewRows(hotelColumns).AddRow(
+			123, "", "Old Hotel", "", "", "", "", "", "", "", 5, 4.5, 0, false, false, "", nil, nil, now, now,
+		))
+	mock.ExpectQuery(`SELECT (.+) FROM reviews WHERE hotel_id = \$1 ORDER BY id ASC`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow(1, 123, 9, "", "", "Jane", "2024-01-15T10:00:00Z", "Lovely", "", "Quiet rooms", "", "", now).
+			AddRow(2, 123, 7, "", "", "John", "2024-02-01T08:30:00Z", "Fine", "", "Coffee", "", "", now).
+			AddRow(3, 123, 4, "", "", "Max", "2023-06-01T09:00:00Z", "Noisy", "", "", "", "", now))
+
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1`).
+		WithArgs(int64(456)).
+		WillReturnError(sql.ErrNoRows)
+
+	stats, ran, _ := app.runSync(context.Background(), app.tiers[0], []string{"123", "456"})
+	if !ran {
+		t.Fatal("expected run to proceed")
+	}
+
+	if stats.succeeded != 2 {
+		t.Errorf("expected 2 hotels to be previewed, got %+v", stats)
+	}
+
+	// No INSERT or UPDATE expectations were registered, so any write would
+	// have failed the run above.
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	output := out.String()
+
+	expected := []string{
+		`~ hotel 123 "Grand Hotel"`,
+		`hotel_name: "Old Hotel" -> "Grand Hotel"`,
+		`+ review "Great" by "Ann"`,
+		`~ review "Fine" by "John"`,
+		`pros: "Coffee" -> "Breakfast"`,
+		`- review "Noisy" by "Max"`,
+		`reviews: 1 new, 1 changed, 1 removed, 1 unchanged`,
+		`+ hotel 456 "New Inn"`,
+		`reviews: 1 new, 0 changed, 0 removed, 0 unchanged`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestHotelDiff_Format(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     hotelDiff
+		expected []string
+	}{
+		{
+			name:     "unchanged",
+			diff:     hotelDiff{hotelID: 1, hotelName: "Same", unchangedReviews: 3},
+			expected: []string{`= hotel 1 "Same" unchanged (3 reviews)`},
+		},
+		{
+			name: "changed fields",
+			diff: hotelDiff{
+				hotelID:   2,
+				hotelName: "Changed",
+				changes:   []data.FieldChange{{Field: "stars", OldValue: "3", NewValue: "4"}},
+			},
+			expected: []string{`~ hotel 2 "Changed"`, `stars: "3" -> "4"`, `reviews: 0 new, 0 changed, 0 removed, 0 unchanged`},
+		},
+		{
+			name: "removed reviews",
+			diff: hotelDiff{
+				hotelID:          3,
+				hotelName:        "Pruned",
+				removedReviews:   []data.Review{{Name: "Max", Headline: "Noisy", Date: "2023-06-01"}},
+				unchangedReviews: 2,
+			},
+			expected: []string{`~ hotel 3 "Pruned"`, `- review "Noisy" by "Max" on 2023-06-01`, `reviews: 0 new, 0 changed, 1 removed, 2 unchanged`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := string(tt.diff.format())
+
+			for _, want := range tt.expected {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+// quarantinePolicy decides when a hotel that keeps failing is quarantined
+// and how long it waits between attempts from then on.
+type quarantinePolicy struct {
+	after     int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// next returns the failure record of hotelID after it failed with err at
+// now, given its previous record, if any. Once the hotel reaches p.after
+// consecutive failures it is quarantined, and the delay before the next
+// attempt doubles with every further failure, capped at p.maxDelay.
+func (p quarantinePolicy) next(prev *data.SyncFailure, hotelID int, err error, now time.Time) *data.SyncFailure {
+	failure := &data.SyncFailure{HotelID: hotelID, ConsecutiveFailures: 1, LastError: err.Error()}
+	if prev != nil {
+		failure.ConsecutiveFailures = prev.ConsecutiveFailures + 1
+		failure.QuarantinedAt = prev.QuarantinedAt
+	}
+
+	if failure.ConsecutiveFailures < p.after {
+		return failure
+	}
+
+	if failure.QuarantinedAt == nil {
+		failure.QuarantinedAt = &now
+	}
+	nextAttempt := now.Add(p.delay(failure.ConsecutiveFailures - p.after))
+	failure.NextAttemptAt = &nextAttempt
+
+	return failure
+}
+
+func (p quarantinePolicy) delay(retries int) time.Duration {
+	delay := p.maxDelay
+	if retries < 32 {
+		if d := p.baseDelay << retries; d > 0 && d < delay {
+			delay = d
+		}
+	}
+
+	return delay
+}
+
+// failureTracker holds the failure records read at the start of a run and
+// updates them as hotels fail or recover.
+type failureTracker struct {
+	policy quarantinePolicy
+	model  data.SyncFailureModel
+	known  map[int]*data.SyncFailure
+	logger *slog.Logger
+}
+
+// newFailureTracker loads the current failure records. It returns nil, which
+// disables tracking for the run, when quarantine is off, during a dry run,
+// or when the records cannot be read, since counting on top of unknown
+// records would reset them.
+func (app *application) newFailureTracker() *failureTracker {
+	if app.config.quarantine.after <= 0 || app.config.dryRun {
+		return nil
+	}
+
+	known, err := app.models.SyncFailures.GetAllByHotel()
+	if err != nil {
+		app.logger.Error("could not load sync failures, quarantine is off for this run", "error", err.Error())
+		return nil
+	}
+
+	return &failureTracker{
+		policy: app.config.quarantine,
+		model:  app.models.SyncFailures,
+		known:  known,
+		logger: app.logger,
+	}
+}
+
+// syncTracked syncs id unless it is quarantined and not yet due for another
+// attempt, and updates its failure record with the outcome.
+func (app *application) syncTracked(ctx context.Context, id string, ft *failureTracker) hotelResult {
+	if ft == nil {
+		return app.syncHotel(ctx, id)
+	}
+
+	hotelID, _ := strconv.Atoi(id)
+	if failure := ft.known[hotelID]; failure != nil && failure.HeldBack(time.Now()) {
+		return hotelResult{
+			id:       id,
+			hotelID:  hotelID,
+			outcome:  outcomeSkipped,
+			err:      fmt.Errorf("quarantined until %s", failure.NextAttemptAt.Format(time.RFC3339)),
+			heldBack: true,
+		}
+	}
+
+	result := app.syncHotel(ctx, id)
+	ft.record(ctx, result)
+
+	return result
+}
+
+// record updates the failure record of the hotel in result. Failures caused
+// by the run being cancelled are not the hotel's fault and are not counted.
+func (ft *failureTracker) record(ctx context.Context, result hotelResult) {
+	if result.hotelID == 0 || ctx.Err() != nil {
+		return
+	}
+
+	prev := ft.known[result.hotelID]
+
+	switch result.outcome {
+	case outcomeSucceeded:
+		if prev == nil {
+			return
+		}
+
+		err := ft.model.Delete(result.hotelID)
+		if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+			ft.logger.Error("could not clear sync failure", "hotel_id", result.hotelID, "error", err.Error())
+			return
+		}
+
+		if prev.QuarantinedAt != nil {
+			ft.logger.Info("hotel left quarantine", "hotel_id", result.hotelID)
+		}
+	case outcomeFailed:
+		failure := ft.policy.next(prev, result.hotelID, result.err, time.Now())
+
+		err := ft.model.Upsert(failure)
+		if err != nil {
+			ft.logger.Error("could not record sync failure", "hotel_id", result.hotelID, "error", err.Error())
+			return
+		}
+
+		if failure.QuarantinedAt != nil && (prev == nil || prev.QuarantinedAt == nil) {
+			ft.logger.Warn("hotel quarantined after repeated failures",
+				"hotel_id", result.hotelID,
+				"failures", failure.ConsecutiveFailures,
+				"next_attempt_at", failure.NextAttemptAt.Format(time.RFC3339),
+			)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+func TestQuarantinePolicy_Next(t *testing.T) {
+	policy := quarantinePolicy{after: 3, baseDelay: time.Hour, maxDelay: 6 * time.Hour}
+	now := time.Now()
+	earlier := now.Add(-24 * time.Hour)
+	syncErr := errors.New("unexpected status code: 500")
+
+	tests := []struct {
+		name            string
+		prev            *data.SyncFailure
+		wantFailures    int
+		wantQuarantined *time.Time
+		wantDelay       time.Duration
+	}{
+		{name: "first failure", wantFailures: 1},
+		{name: "below threshold", prev: &data.SyncFailure{ConsecutiveFailures: 1}, wantFailures: 2},
+		{name: "reaches threshold", prev: &data.SyncFailure{ConsecutiveFailures: 2}, wantFailures: 3, wantQuarantined: &now, wantDelay: time.Hour},
+		{name: "doubles delay", prev: &data.SyncFailure{ConsecutiveFailures: 4, QuarantinedAt: &earlier}, wantFailures: 5, wantQuarantined: &earlier, wantDelay: 4 * time.Hour},
+		{name: "caps delay", prev: &data.SyncFailure{ConsecutiveFailures: 40, QuarantinedAt: &earlier}, wantFailures: 41, wantQuarantined: &earlier, wantDelay: 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := policy.next(tt.prev, 7, syncErr, now)
+
+			if failure.HotelID != 7 || failure.LastError != syncErr.Error() {
+				t.Errorf("unexpected failure record: %+v", failure)
+			}
+			if failure.ConsecutiveFailures != tt.wantFailures {
+				t.Errorf("expected %d failures, got %d", tt.wantFailures, failure.ConsecutiveFailures)
+			}
+
+			if tt.wantQuarantined == nil {
+				if failure.QuarantinedAt != nil || failure.NextAttemptAt != nil {
+					t.Errorf("expected hotel not to be quarantined, got %+v", failure)
+				}
+				return
+			}
+
+			if failure.QuarantinedAt == nil || !failure.QuarantinedAt.Equal(*tt.wantQuarantined) {
+				t.Errorf("expected quarantined at %v, got %v", tt.wantQuarantined, failure.QuarantinedAt)
+			}
+			if failure.NextAttemptAt == nil || failure.NextAttemptAt.Sub(now) != tt.wantDelay {
+				t.Errorf("expected next attempt after %s, got %v", tt.wantDelay, failure.NextAttemptAt)
+			}
+		})
+	}
+}
+
+func TestSyncTracked(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3.0/property/1" {
+			t.Error("expected a quarantined hotel not to be fetched")
+		}
+		http.NotFound(w, r)
+	}))
+	defer cupid.Close()
+
+	app, mock := newTestApplication(t, cupid.URL)
+	app.config.quarantine = quarantinePolicy{after: 2, baseDelay: time.Hour, maxDelay: 24 * time.Hour}
+
+	now := time.Now()
+	columns := []string{"hotel_id", "consecutive_failures", "last_error", "first_failed_at", "last_failed_at", "quarantined_at", "next_attempt_at"}
+
+	mock.ExpectQuery(`SELECT (.+) FROM sync_failures`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 3, "timeout", now, now, now, now.Add(time.Hour)).
+			AddRow(2, 1, "timeout", now, now, nil, nil))
+
+	ft := app.newFailureTracker()
+	if ft == nil {
+		t.Fatal("expected failure tracking to be on")
+	}
+
+	result := app.syncTracked(context.Background(), "1", ft)
+	if result.outcome != outcomeSkipped || !result.heldBack {
+		t.Errorf("expected quarantined hotel to be held back, got %+v", result)
+	}
+
+	mock.ExpectQuery(`INSERT INTO sync_failures`).
+		WithArgs(2, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"first_failed_at", "last_failed_at"}).AddRow(now, now))
+
+	result = app.syncTracked(context.Background(), "2", ft)
+	if result.outcome != outcomeFailed {
+		t.Errorf("expected hotel 2 to fail, got %+v", result)
+	}
+
+	mock.ExpectExec(`DELETE FROM sync_failures WHERE hotel_id = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ft.record(context.Background(), hotelResult{hotelID: 1, outcome: outcomeSucceeded})
+
+	// A hotel without a failure record has nothing to clear.
+	ft.record(context.Background(), hotelResult{hotelID: 3, outcome: outcomeSucceeded})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestNewFailureTracker_Disabled(t *testing.T) {
+	app, mock := newTestApplication(t, "")
+
+	if app.newFailureTracker() != nil {
+		t.Error("expected tracking to be off without -quarantine-after")
+	}
+
+	app.config.quarantine.after = 3
+	app.config.dryRun = true
+	if app.newFailureTracker() != nil {
+		t.Error("expected tracking to be off during a dry run")
+	}
+
+	app.config.dryRun = false
+	mock.ExpectQuery(`SELECT (.+) FROM sync_failures`).
+		WillReturnError(errors.New("connection refused"))
+
+	if app.newFailureTracker() != nil {
+		t.Error("expected tracking to be off when failures cannot be loaded")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+	"time"
+)
+
+// syncLock keeps sync runs from overlapping across instances. tryAcquire
+// does not block: ok is false when another instance holds the lock. On
+// success the returned context is cancelled if the lock is lost during the
+// run, and release must be called once the run is over.
+type syncLock interface {
+	tryAcquire(ctx context.Context) (lockCtx context.Context, release func(), ok bool, err error)
+}
+
+// advisoryLock is a syncLock backed by a Postgres session-level advisory
+// lock. The lock lives on a dedicated connection held for the duration of
+// the run; if the holder dies or its connection drops, Postgres releases the
+// lock and the next instance to tick takes over.
+type advisoryLock struct {
+	db            *sql.DB
+	key           int64
+	checkInterval time.Duration
+	logger        *slog.Logger
+}
+
+func newAdvisoryLock(db *sql.DB, key int64, logger *slog.Logger) *advisoryLock {
+	return &advisoryLock{db: db, key: key, checkInterval: 10 * time.Second, logger: logger}
+}
+
+func (l *advisoryLock) tryAcquire(ctx context.Context) (context.Context, func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var acquired bool
+	err = conn.QueryRowContext(queryCtx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired)
+	if err != nil {
+		// The lock may have been taken before the query failed, so the
+		// connection must not go back to the pool holding it.
+		discardConn(conn)
+		return nil, nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil, false, nil
+	}
+
+	lockCtx, cancelLock := context.WithCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		l.watch(lockCtx, conn, done, cancelLock)
+	}()
+
+	release := func() {
+		close(done)
+		<-stopped
+		cancelLock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		var unlocked bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&unlocked)
+		if err != nil || !unlocked {
+			// Close only returns the connection to the pool, where it would
+			// keep holding the lock, so it is discarded instead: ending the
+			// session is what makes Postgres release the lock.
+			l.logger.Warn("could not release sync lock, discarding its connection", "key", l.key, "error", err)
+			discardConn(conn)
+			return
+		}
+
+		conn.Close()
+	}
+
+	return lockCtx, release, true, nil
+}
+
+// watch checks the lock connection until done is closed, cancelling the run
+// as soon as the connection, and with it the lock, is gone.
+func (l *advisoryLock) watch(ctx context.Context, conn *sql.Conn, done <-chan struct{}, cancel context.CancelFunc) {
+	ticker := time.NewTicker(l.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, 3*time.Second)
+			_, err := conn.ExecContext(pingCtx, "SELECT 1")
+			pingCancel()
+
+			if err != nil && ctx.Err() == nil {
+				l.logger.Error("lost sync lock connection, stopping run", "key", l.key, "error", err.Error())
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// discardConn closes conn's underlying session instead of returning it to
+// the pool: database/sql drops a connection whose Raw callback reports
+// driver.ErrBadConn.
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
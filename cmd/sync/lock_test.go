@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newTestAdvisoryLock(t *testing.T) (*advisoryLock, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return newAdvisoryLock(db, 42, slog.New(slog.NewTextHandler(io.Discard, nil))), mock
+}
+
+func TestAdvisoryLock_Acquire(t *testing.T) {
+	lock, mock := newTestAdvisoryLock(t)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectQuery(`SELECT pg_advisory_unlock\(\$1\)`).
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_advisory_unlock"}).AddRow(true))
+
+	lockCtx, release, ok, err := lock.tryAcquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Fatal("expected the lock to be acquired")
+	}
+	if lockCtx.Err() != nil {
+		t.Error("expected the lock context to be live while the lock is held")
+	}
+
+	release()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAdvisoryLock_UnlockFails(t *testing.T) {
+	lock, mock := newTestAdvisoryLock(t)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectQuery(`SELECT pg_advisory_unlock\(\$1\)`).
+		WithArgs(int64(42)).
+		WillReturnError(context.DeadlineExceeded)
+
+	_, release, ok, err := lock.tryAcquire(context.Background())
+	if err != nil || !ok {
+		t.Fatalf("expected the lock to be acquired, got ok=%v err=%v", ok, err)
+	}
+
+	release()
+
+	// The connection still holding the lock must not go back to the pool.
+	if open := lock.db.Stats().OpenConnections; open != 0 {
+		t.Errorf("expected the lock connection to be discarded, %d still open", open)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAdvisoryLock_HeldElsewhere(t *testing.T) {
+	lock, mock := newTestAdvisoryLock(t)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	_, _, ok, err := lock.tryAcquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ok {
+		t.Error("expected the lock not to be acquired")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAdvisoryLock_ConnectionLost(t *testing.T) {
+	lock, mock := newTestAdvisoryLock(t)
+	lock.checkInterval = 10 * time.Millisecond
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec(`SELECT 1`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT 1`).
+		WillReturnError(errors.New("connection reset by peer"))
+	mock.ExpectQuery(`SELECT pg_advisory_unlock\(\$1\)`).
+		WillReturnError(errors.New("connection reset by peer"))
+
+	lockCtx, release, ok, err := lock.tryAcquire(context.Background())
+	if err != nil || !ok {
+		t.Fatalf("expected the lock to be acquired, got ok=%v err=%v", ok, err)
+	}
+
+	select {
+	case <-lockCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the lock context to be cancelled once the connection was lost")
+	}
+
+	release()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+type fakeLock struct {
+	ok       bool
+	err      error
+	released bool
+}
+
+func (l *fakeLock) tryAcquire(ctx context.Context) (context.Context, func(), bool, error) {
+	if l.err != nil || !l.ok {
+		return nil, nil, false, l.err
+	}
+	return ctx, func() { l.released = true }, true, nil
+}
+
+func TestRunSync_Lock(t *testing.T) {
+	tests := []struct {
+		name    string
+		lock    *fakeLock
+		wantRan bool
+		wantErr bool
+	}{
+		{name: "held elsewhere", lock: &fakeLock{ok: false}},
+		{name: "lock error", lock: &fakeLock{err: errors.New("connection refused")}, wantErr: true},
+		{name: "acquired", lock: &fakeLock{ok: true}, wantRan: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, mock := newTestApplication(t, "")
+			tier := app.tiers[0]
+			tier.lock = tt.lock
+
+			if tt.wantRan {
+				mock.ExpectQuery(`INSERT INTO sync_runs`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(1, time.Now()))
+				mock.ExpectQuery(`UPDATE sync_runs`).
+					WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(time.Now()))
+			}
+
+			_, ran, err := app.runSync(context.Background(), tier, []string{"not-a-number"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error to be %v, got %v", tt.wantErr, err)
+			}
+			if ran != tt.wantRan {
+				t.Errorf("expected ran to be %v, got %v", tt.wantRan, ran)
+			}
+			if tt.lock.released != tt.wantRan {
+				t.Errorf("expected released to be %v, got %v", tt.wantRan, tt.lock.released)
+			}
+			if tier.running.Load() {
+				t.Error("expected running flag to be cleared")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
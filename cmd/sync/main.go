@@ -3,143 +3,174 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"flag"
-	"fmt"
+	"io"
 	"log/slog"
-	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/JLL32/nuitee/internal/data"
 	"github.com/go-co-op/gocron"
 	_ "github.com/lib/pq"
+	"golang.org/x/time/rate"
 )
 
+// defaultLockKey is the advisory lock key used when -lock-key is not set.
+// Instances syncing the same database must share it.
+const defaultLockKey int64 = 0x6e756974656573
+
+type config struct {
+	source             string
+	inputFile          string
+	inputFormat        string
+	watch              bool
+	dsn                string
+	apiKey             string
+	apiUrl             string
+	interval           int
+	cron               string
+	tiers              tierSchedules
+	workers            int
+	reviewWindow       int
+	fullResyncInterval time.Duration
+	retry              retryPolicy
+	quarantine         quarantinePolicy
+	limiter            struct {
+		rps     float64
+		burst   int
+		enabled bool
+	}
+	metricsAddr string
+	once        bool
+	dryRun      bool
+	lockKey     int64
+}
+
+type application struct {
+	config config
+	logger *slog.Logger
+	db     *sql.DB
+	models *data.Models
+	cupid  *cupidClient
+	source idSource
+	tiers  []*tier
+	out    io.Writer
+	outMu  sync.Mutex
+}
+
 func main() {
-	var (
-		inputFile string
-		dsn       string
-		apiKey    string
-		apiUrl    string
-		interval  int
-	)
-
-	flag.StringVar(&inputFile, "input", "", "Input file path")
-	flag.StringVar(&dsn, "db-dsn", "", "Database connection string")
-	flag.StringVar(&apiKey, "api-key", "", "API key for authentication")
-	flag.StringVar(&apiUrl, "api-url", "", "API URL for fetching data")
-	flag.IntVar(&interval, "interval", 3, "Interval in minutes")
+	cfg := config{tiers: tierSchedules{}}
+
+	flag.StringVar(&cfg.source, "source", "file", "Where hotel IDs are read from (file|stdin|db)")
+	flag.StringVar(&cfg.inputFile, "input", "", "Input file path")
+	flag.StringVar(&cfg.inputFormat, "input-format", "comma", "Format of the input file or stdin (comma|lines|csv)")
+	flag.BoolVar(&cfg.watch, "watch", false, "Re-read the input file before each run when it has changed")
+	flag.StringVar(&cfg.dsn, "db-dsn", "", "Database connection string")
+	flag.StringVar(&cfg.apiKey, "api-key", "", "API key for authentication")
+	flag.StringVar(&cfg.apiUrl, "api-url", "", "API URL for fetching data")
+	flag.IntVar(&cfg.interval, "interval", 3, "Interval in minutes")
+	flag.StringVar(&cfg.cron, "cron", "", "Cron expression for the default tier, overrides -interval")
+	flag.Var(cfg.tiers, "tier", "Refresh tier schedule as name=cron-expression (repeatable)")
+	flag.IntVar(&cfg.workers, "workers", 8, "Number of hotels synced concurrently")
+	flag.IntVar(&cfg.reviewWindow, "review-window", 100, "Number of newest reviews fetched per hotel between full resyncs (0 always fetches the full history)")
+	flag.DurationVar(&cfg.fullResyncInterval, "full-resync-interval", 24*time.Hour, "How often a hotel's full review history is re-fetched (0 only on divergence)")
+	flag.IntVar(&cfg.retry.maxAttempts, "retry-max-attempts", 3, "Maximum attempts per Cupid request")
+	flag.DurationVar(&cfg.retry.baseDelay, "retry-base-delay", 500*time.Millisecond, "Initial delay between Cupid request retries")
+	flag.DurationVar(&cfg.retry.maxDelay, "retry-max-delay", 30*time.Second, "Maximum delay between Cupid request retries, including one asked for with Retry-After")
+	flag.IntVar(&cfg.quarantine.after, "quarantine-after", 5, "Consecutive failures before a hotel is quarantined (0 disables quarantine)")
+	flag.DurationVar(&cfg.quarantine.baseDelay, "quarantine-base-delay", time.Hour, "Initial delay between attempts for a quarantined hotel")
+	flag.DurationVar(&cfg.quarantine.maxDelay, "quarantine-max-delay", 7*24*time.Hour, "Maximum delay between attempts for a quarantined hotel")
+	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 10, "Maximum Cupid API requests per second across all workers")
+	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 10, "Maximum burst of Cupid API requests")
+	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable the outbound Cupid API rate limiter")
+	flag.StringVar(&cfg.metricsAddr, "metrics-addr", "", "Address to serve /debug/vars metrics on (disabled when empty)")
+	flag.BoolVar(&cfg.once, "once", false, "Run a single sync pass and exit, with a non-zero status if any hotel failed")
+	flag.BoolVar(&cfg.dryRun, "dry-run", false, "Fetch hotels and print what would change without writing to the database")
+	flag.Int64Var(&cfg.lockKey, "lock-key", defaultLockKey, "Postgres advisory lock key shared by all sync instances")
 	flag.Parse()
 
-	if inputFile == "" || dsn == "" || apiKey == "" || apiUrl == "" {
+	if cfg.dsn == "" || cfg.apiKey == "" || cfg.apiUrl == "" || cfg.workers < 1 || cfg.retry.maxAttempts < 1 || cfg.reviewWindow < 0 ||
+		cfg.limiter.enabled && (cfg.limiter.rps <= 0 || cfg.limiter.burst < 1) {
 		flag.Usage()
 		return
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	slog.SetDefault(logger)
-
-	inputData, err := os.ReadFile(inputFile)
-	if err != nil {
-		slog.Error(err.Error())
-		panic(err)
+	// In dry-run mode stdout is reserved for the diff.
+	logOutput := os.Stdout
+	if cfg.dryRun {
+		logOutput = os.Stderr
 	}
 
-	ids := strings.Split(string(inputData), ",")
-	for i := range ids {
-		ids[i] = strings.TrimSpace(ids[i])
-	}
+	logger := slog.New(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	slog.SetDefault(logger)
 
-	db, err := openDB(dsn)
+	db, err := openDB(cfg.dsn)
 	if err != nil {
-		slog.Error(err.Error())
-		panic(err)
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 	defer db.Close()
-	slog.Info("Database connection established")
+	logger.Info("Database connection established")
 
-	s := gocron.NewScheduler(time.UTC)
-	s.Every(interval).Minutes().Do(func() {
-		slog.Info("Starting sync")
-		for _, id := range ids {
-			var hotel data.Hotel
-			for range 3 {
-				err = fetchJson(fmt.Sprintf("%s/v3.0/property/%s", apiUrl, id), map[string]string{"x-api-key": apiKey}, &hotel)
-				if err == nil {
-					break
-				}
-			}
-			if err != nil {
-				slog.Error(fmt.Sprintf("Error fetching hotel data for ID %s: %v", id, err))
-				continue
-			}
-
-			var reviews []data.Review
-			for range 3 {
-				err = fetchJson(fmt.Sprintf("%s/v3.0/property/reviews/%s/1000000", apiUrl, id), map[string]string{"x-api-key": apiKey}, &reviews)
-				if err == nil {
-					break
-				}
-			}
-			if err != nil {
-				slog.Error(fmt.Sprintf("Error fetching review data for ID %s: %v", id, err))
-				continue
-			}
-
-			models := data.NewModels(db)
-			err = models.Hotels.Upsert(&hotel)
-			if err != nil {
-				slog.Error(fmt.Sprintf("Error inserting hotel data for ID %s: %v", id, err))
-				continue
-			}
-
-			for _, review := range reviews {
-				err = models.Reviews.Upsert(hotel.HotelID, &review)
-				if err != nil {
-					slog.Error(fmt.Sprintf("Error inserting review data for ID %s: %v", id, err))
-					continue
-				}
-			}
-		}
-	})
-	s.StartBlocking()
+	var limiter *rate.Limiter
+	if cfg.limiter.enabled {
+		limiter = rate.NewLimiter(rate.Limit(cfg.limiter.rps), cfg.limiter.burst)
+	}
 
-}
+	models := data.NewModels(db)
 
-func fetchJson(url string, headers map[string]string, output any) error {
-	req, err := http.NewRequest("GET", url, nil)
+	source, err := newSource(cfg, models, os.Stdin, logger)
 	if err != nil {
-		return err
+		logger.Error(err.Error())
+		db.Close()
+		os.Exit(1)
 	}
 
-	for key, value := range headers {
-		req.Header.Add(key, value)
+	app := &application{
+		config: cfg,
+		logger: logger,
+		db:     db,
+		models: models,
+		cupid:  newCupidClient(cfg.apiUrl, cfg.apiKey, cfg.retry, limiter, logger),
+		source: source,
+		tiers:  newTiers(cfg, db, logger),
+		out:    os.Stdout,
 	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	app.publishMetrics(db)
+	if cfg.metricsAddr != "" {
+		go app.serveMetrics()
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	if cfg.once {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		stats, err := app.syncAllTiers(ctx)
+		stop()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	decoder := json.NewDecoder(resp.Body)
+		if err != nil {
+			logger.Error(err.Error())
+		}
 
-	err = decoder.Decode(output)
-	if err != nil {
-		return err
+		if err != nil || stats.failed > 0 || ctx.Err() != nil {
+			db.Close()
+			os.Exit(1)
+		}
+		return
 	}
 
-	return nil
+	s := gocron.NewScheduler(time.UTC)
+	for _, t := range app.tiers {
+		err = app.schedule(s, t)
+		if err != nil {
+			logger.Error(err.Error())
+			db.Close()
+			os.Exit(1)
+		}
+	}
+	s.StartBlocking()
 }
 
 func openDB(dsn string) (*sql.DB, error) {
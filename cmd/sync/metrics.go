@@ -0,0 +1,42 @@
+package main
+
+import (
+	"database/sql"
+	"expvar"
+	"net/http"
+	"time"
+)
+
+func (app *application) publishMetrics(db *sql.DB) {
+	expvar.Publish("database", expvar.Func(func() any {
+		return db.Stats()
+	}))
+	expvar.Publish("cupid_requests", expvar.Func(func() any {
+		return app.cupid.requests.Load()
+	}))
+	expvar.Publish("cupid_rate_limit_wait_microsec", expvar.Func(func() any {
+		return time.Duration(app.cupid.limiterWait.Load()).Microseconds()
+	}))
+	expvar.Publish("cupid_rate_limit_delays", expvar.Func(func() any {
+		return app.cupid.limiterDelays.Load()
+	}))
+}
+
+func (app *application) serveMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+
+	srv := &http.Server{
+		Addr:        app.config.metricsAddr,
+		Handler:     mux,
+		IdleTimeout: time.Minute,
+		ReadTimeout: 5 * time.Second,
+	}
+
+	app.logger.Info("serving metrics", "addr", srv.Addr)
+
+	err := srv.ListenAndServe()
+	if err != nil {
+		app.logger.Error(err.Error())
+	}
+}
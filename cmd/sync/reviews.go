@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+// fullReviewCount is the number of reviews requested when fetching a hotel's
+// full review history. Cupid returns reviews newest first.
+const fullReviewCount = 1000000
+
+// reviewPlan says which reviews to fetch for a hotel. Between full resyncs
+// only the newest -review-window reviews are fetched.
+type reviewPlan struct {
+	full   bool
+	reason string
+	newest time.Time
+}
+
+// planReviews decides whether a hotel needs its full review history: when
+// incremental sync is disabled, when it has never had a full resync, when
+// its review count diverged last time, or when the last full resync is older
+// than -full-resync-interval.
+func (app *application) planReviews(hotelID int) (reviewPlan, error) {
+	if app.config.reviewWindow <= 0 {
+		return reviewPlan{full: true}, nil
+	}
+
+	state, err := app.models.HotelSyncState.Get(hotelID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		return reviewPlan{full: true, reason: "first sync"}, nil
+	case err != nil:
+		return reviewPlan{}, err
+	case state.FullResyncDue:
+		return reviewPlan{full: true, reason: "review count diverged"}, nil
+	case app.config.fullResyncInterval > 0 && time.Since(state.LastFullSyncAt) >= app.config.fullResyncInterval:
+		return reviewPlan{full: true, reason: "scheduled"}, nil
+	}
+
+	return reviewPlan{newest: state.NewestReviewAt}, nil
+}
+
+// fetchReviews fetches the reviews plan asks for. A window that comes back
+// short already holds every review, so plan is upgraded to a full sync. A
+// full window that is entirely newer than the newest stored review may have
+// skipped reviews in between, so the full history is fetched instead.
+func (app *application) fetchReviews(ctx context.Context, id string, plan *reviewPlan) ([]data.Review, error) {
+	if plan.full {
+		app.logger.Debug("fetching full review history", "hotel_id", id, "reason", plan.reason)
+		return app.cupid.getReviews(ctx, id, fullReviewCount)
+	}
+
+	reviews, err := app.cupid.getReviews(ctx, id, app.config.reviewWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reviews) < app.config.reviewWindow {
+		plan.full = true
+		return reviews, nil
+	}
+
+	overlaps := slices.ContainsFunc(reviews, func(review data.Review) bool {
+		t, ok := review.ParseDate()
+		return ok && !t.After(plan.newest)
+	})
+	if overlaps {
+		return reviews, nil
+	}
+
+	plan.full = true
+	plan.reason = "review window has no overlap with stored reviews"
+	app.logger.Debug("fetching full review history", "hotel_id", id, "reason", plan.reason)
+
+	return app.cupid.getReviews(ctx, id, fullReviewCount)
+}
+
+// recordReviewSync updates the hotel's sync state as part of tx once its
+// reviews are written. It reports whether the next sync must be a full
+// resync. Nothing is recorded when incremental sync is disabled.
+func (app *application) recordReviewSync(tx *sql.Tx, hotel *data.Hotel, plan reviewPlan) (bool, error) {
+	if app.config.reviewWindow <= 0 {
+		return false, nil
+	}
+
+	if plan.full {
+		return false, app.models.HotelSyncState.RecordFullSyncTx(tx, hotel.HotelID, hotel.ReviewCount)
+	}
+
+	return app.models.HotelSyncState.RecordIncrementalSyncTx(tx, hotel.HotelID, hotel.ReviewCount)
+}
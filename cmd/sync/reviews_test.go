@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var syncStateColumns = []string{
+	"hotel_id", "newest_review_at", "review_count_offset", "full_resync_due", "last_full_sync_at", "updated_at",
+}
+
+func TestPlanReviews(t *testing.T) {
+	newest := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		window   int
+		state    *sqlmock.Rows
+		err      error
+		wantFull bool
+		wantErr  bool
+	}{
+		{name: "incremental disabled", window: 0, wantFull: true},
+		{name: "first sync", window: 50, err: sql.ErrNoRows, wantFull: true},
+		{name: "state error", window: 50, err: errors.New("connection refused"), wantErr: true},
+		{
+			name:     "resync due",
+			window:   50,
+			state:    sqlmock.NewRows(syncStateColumns).AddRow(1, newest, 0, true, time.Now(), time.Now()),
+			wantFull: true,
+		},
+		{
+			name:     "scheduled resync",
+			window:   50,
+			state:    sqlmock.NewRows(syncStateColumns).AddRow(1, newest, 0, false, time.Now().Add(-25*time.Hour), time.Now()),
+			wantFull: true,
+		},
+		{
+			name:   "incremental",
+			window: 50,
+			state:  sqlmock.NewRows(syncStateColumns).AddRow(1, newest, 0, false, time.Now().Add(-time.Hour), time.Now()),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, mock := newTestApplication(t, "")
+			app.config.reviewWindow = tt.window
+			app.config.fullResyncInterval = 24 * time.Hour
+
+			if tt.window > 0 {
+				query := mock.ExpectQuery(`SELECT (.+) FROM hotel_sync_state WHERE hotel_id = \$1`).WithArgs(1)
+				if tt.err != nil {
+					query.WillReturnError(tt.err)
+				} else {
+					query.WillReturnRows(tt.state)
+				}
+			}
+
+			plan, err := app.planReviews(1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error to be %v, got %v", tt.wantErr, err)
+			}
+			if plan.full != tt.wantFull {
+				t.Errorf("expected full to be %v, got %+v", tt.wantFull, plan)
+			}
+			if !tt.wantErr && !tt.wantFull && !plan.newest.Equal(newest) {
+				t.Errorf("expected newest review date %v, got %v", newest, plan.newest)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestFetchReviews(t *testing.T) {
+	window := `[
+		{"name": "Ann", "date": "2024-03-03 12:00:00", "headline": "Great"},
+		{"name": "Bob", "date": "2024-03-02 12:00:00", "headline": "Good"},
+		{"name": "Cat", "date": "2024-03-01 12:00:00", "headline": "Fine"}
+	]`
+	full := `[
+		{"name": "Ann", "date": "2024-03-03 12:00:00", "headline": "Great"},
+		{"name": "Bob", "date": "2024-03-02 12:00:00", "headline": "Good"},
+		{"name": "Cat", "date": "2024-03-01 12:00:00", "headline": "Fine"},
+		{"name": "Dan", "date": "2024-02-01 12:00:00", "headline": "Ok"}
+	]`
+
+	tests := []struct {
+		name         string
+		plan         reviewPlan
+		window       int
+		wantFull     bool
+		wantReviews  int
+		wantRequests []string
+	}{
+		{
+			name:         "full",
+			plan:         reviewPlan{full: true},
+			window:       3,
+			wantFull:     true,
+			wantReviews:  4,
+			wantRequests: []string{"/v3.0/property/reviews/1/1000000"},
+		},
+		{
+			name:         "window overlaps stored reviews",
+			plan:         reviewPlan{newest: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+			window:       3,
+			wantReviews:  3,
+			wantRequests: []string{"/v3.0/property/reviews/1/3"},
+		},
+		{
+			name:         "window short of full",
+			plan:         reviewPlan{newest: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+			window:       10,
+			wantFull:     true,
+			wantReviews:  3,
+			wantRequests: []string{"/v3.0/property/reviews/1/10"},
+		},
+		{
+			name:         "gap after stored reviews",
+			plan:         reviewPlan{newest: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			window:       3,
+			wantFull:     true,
+			wantReviews:  4,
+			wantRequests: []string{"/v3.0/property/reviews/1/3", "/v3.0/property/reviews/1/1000000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path)
+				if r.URL.Path == "/v3.0/property/reviews/1/1000000" {
+					io.WriteString(w, full)
+					return
+				}
+				io.WriteString(w, window)
+			}))
+			defer cupid.Close()
+
+			app, _ := newTestApplication(t, cupid.URL)
+			app.config.reviewWindow = tt.window
+
+			plan := tt.plan
+			reviews, err := app.fetchReviews(context.Background(), "1", &plan)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if plan.full != tt.wantFull {
+				t.Errorf("expected full to be %v, got %v", tt.wantFull, plan.full)
+			}
+			if len(reviews) != tt.wantReviews {
+				t.Errorf("expected %d reviews, got %d", tt.wantReviews, len(reviews))
+			}
+			if len(requests) != len(tt.wantRequests) {
+				t.Fatalf("expected requests %v, got %v", tt.wantRequests, requests)
+			}
+			for i := range requests {
+				if requests[i] != tt.wantRequests[i] {
+					t.Errorf("expected requests %v, got %v", tt.wantRequests, requests)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncHotel_IncrementalReviews(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.0/property/123":
+			io.WriteString(w, `{"hotel_id": 123, "hotel_name": "Test Hotel", "review_count": 12}`)
+		case "/v3.0/property/reviews/123/2":
+			io.WriteString(w, `[
+				{"name": "Jane", "date": "2024-03-02 10:00:00", "headline": "Lovely"},
+				{"name": "John", "date": "2024-03-01 12:00:00", "headline": "Fine"}
+			]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cupid.Close()
+
+	app, mock := newTestApplication(t, cupid.URL)
+	app.config.reviewWindow = 2
+
+	now := time.Now()
+	newest := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_sync_state WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows(syncStateColumns).AddRow(123, newest, 2, false, now, now))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+		WithArgs(int64(123)).
+		WillReturnRows(sqlmock.NewRows(hotelColumns).AddRow(
+			123, "", "Test Hotel", "", "", "", "", "", "", "", 0, 0, 12, false, false, "", nil, nil, now, now,
+		))
+	mock.ExpectQuery(`INSERT INTO reviews`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "inserted"}).AddRow(5, true).AddRow(4, false))
+	// Only a window was fetched, so no stored reviews are deleted.
+	mock.ExpectQuery(`UPDATE hotel_sync_state`).
+		WithArgs(123, 12).
+		WillReturnRows(sqlmock.NewRows([]string{"full_resync_due"}).AddRow(false))
+	mock.ExpectCommit()
+
+	result := app.syncHotel(context.Background(), "123")
+	if result.outcome != outcomeSucceeded {
+		t.Fatalf("expected success, got %v: %v", result.outcome, result.err)
+	}
+	if result.fullResync {
+		t.Error("expected an incremental sync")
+	}
+	if result.reviewsInserted != 1 || result.reviewsUpdated != 1 || result.reviewsRemoved != 0 {
+		t.Errorf("unexpected review counts: %+v", result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
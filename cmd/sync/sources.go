@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+// trackedHotel is a hotel ID to sync and the refresh tier it belongs to.
+type trackedHotel struct {
+	id   string
+	tier string
+}
+
+// idSource yields the hotels to sync. It is consulted at the start of every
+// run, so sources backed by something that changes pick up added and removed
+// hotels without a restart.
+type idSource interface {
+	Hotels(ctx context.Context) ([]trackedHotel, error)
+}
+
+type parseFunc func([]byte) ([]trackedHotel, error)
+
+var inputFormats = map[string]parseFunc{
+	"comma": parseCommaIDs,
+	"lines": parseLineIDs,
+	"csv":   parseCSVIDs,
+}
+
+// staticSource is a fixed list of hotels read once at startup.
+type staticSource []trackedHotel
+
+func (s staticSource) Hotels(ctx context.Context) ([]trackedHotel, error) {
+	return s, nil
+}
+
+func newReaderSource(r io.Reader, parse parseFunc) (staticSource, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	hotels, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return staticSource(hotels), nil
+}
+
+func newFileSource(path string, parse parseFunc) (staticSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return newReaderSource(f, parse)
+}
+
+// watchedFileSource re-reads its file whenever the modification time or
+// size changes, and otherwise serves the IDs it read last. If a re-read
+// fails the previous IDs are kept.
+type watchedFileSource struct {
+	path   string
+	parse  parseFunc
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	hotels  []trackedHotel
+	loaded  bool
+}
+
+func newWatchedFileSource(path string, parse parseFunc, logger *slog.Logger) *watchedFileSource {
+	return &watchedFileSource{path: path, parse: parse, logger: logger}
+}
+
+func (s *watchedFileSource) Hotels(ctx context.Context) ([]trackedHotel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return s.fallback(err)
+	}
+
+	if s.loaded && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.hotels, nil
+	}
+
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return s.fallback(err)
+	}
+
+	hotels, err := s.parse(b)
+	if err != nil {
+		return s.fallback(err)
+	}
+
+	if s.loaded {
+		added, removed := diffHotels(s.hotels, hotels)
+		s.logger.Info("hotel ID file changed", "path", s.path, "added", added, "removed", removed, "total", len(hotels))
+	}
+
+	s.hotels = hotels
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	s.loaded = true
+
+	return s.hotels, nil
+}
+
+func (s *watchedFileSource) fallback(err error) ([]trackedHotel, error) {
+	if !s.loaded {
+		return nil, err
+	}
+
+	s.logger.Warn("could not re-read hotel ID file, keeping previous IDs", "path", s.path, "error", err.Error())
+	return s.hotels, nil
+}
+
+// dbSource reads the enabled rows of the tracked_hotels table on every run.
+type dbSource struct {
+	model data.TrackedHotelModel
+}
+
+func (s dbSource) Hotels(ctx context.Context) ([]trackedHotel, error) {
+	tracked, err := s.model.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	hotels := make([]trackedHotel, len(tracked))
+	for i, t := range tracked {
+		hotels[i] = trackedHotel{id: strconv.Itoa(t.HotelID), tier: cmp.Or(t.Tier, data.DefaultTier)}
+	}
+
+	return hotels, nil
+}
+
+// parseCommaIDs reads the original input.txt format: IDs separated by
+// commas, with any surrounding whitespace ignored.
+func parseCommaIDs(b []byte) ([]trackedHotel, error) {
+	return uniqueIDs(strings.Split(string(b), ",")), nil
+}
+
+// parseLineIDs reads one ID per line. Blank lines and lines starting with #
+// are ignored.
+func parseLineIDs(b []byte) ([]trackedHotel, error) {
+	var ids []string
+	for line := range strings.Lines(string(b)) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+
+	return uniqueIDs(ids), nil
+}
+
+// parseCSVIDs reads hotel IDs from the first column of a CSV file. A first
+// row whose first column is not a number is treated as a header; if it names
+// a "tier" column, that column assigns each hotel its refresh tier.
+func parseCSVIDs(b []byte) ([]trackedHotel, error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing CSV: %w", err)
+	}
+
+	var hotels []trackedHotel
+	tierColumn := -1
+
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(record[0])); i == 0 && err != nil {
+			tierColumn = slices.IndexFunc(record, func(name string) bool {
+				return strings.EqualFold(strings.TrimSpace(name), "tier")
+			})
+			continue
+		}
+
+		hotel := trackedHotel{id: record[0]}
+		if tierColumn > 0 && tierColumn < len(record) {
+			hotel.tier = strings.TrimSpace(record[tierColumn])
+		}
+		hotels = append(hotels, hotel)
+	}
+
+	return uniqueHotels(hotels), nil
+}
+
+// uniqueIDs turns ids into default-tier hotels, see uniqueHotels.
+func uniqueIDs(ids []string) []trackedHotel {
+	hotels := make([]trackedHotel, len(ids))
+	for i, id := range ids {
+		hotels[i] = trackedHotel{id: id}
+	}
+
+	return uniqueHotels(hotels)
+}
+
+// uniqueHotels trims IDs, fills in the default tier and drops blanks and
+// duplicates, keeping the first occurrence of each ID.
+func uniqueHotels(hotels []trackedHotel) []trackedHotel {
+	seen := make(map[string]bool, len(hotels))
+	unique := []trackedHotel{}
+
+	for _, hotel := range hotels {
+		hotel.id = strings.TrimSpace(hotel.id)
+		if hotel.id == "" || seen[hotel.id] {
+			continue
+		}
+		seen[hotel.id] = true
+		hotel.tier = cmp.Or(hotel.tier, data.DefaultTier)
+		unique = append(unique, hotel)
+	}
+
+	return unique
+}
+
+func diffHotels(old, new []trackedHotel) (added, removed int) {
+	oldIDs := make(map[string]bool, len(old))
+	for _, hotel := range old {
+		oldIDs[hotel.id] = true
+	}
+
+	newIDs := make(map[string]bool, len(new))
+	for _, hotel := range new {
+		newIDs[hotel.id] = true
+		if !oldIDs[hotel.id] {
+			added++
+		}
+	}
+
+	for id := range oldIDs {
+		if !newIDs[id] {
+			removed++
+		}
+	}
+
+	return added, removed
+}
+
+// newSource builds the ID source selected by the -source, -input,
+// -input-format and -watch flags.
+func newSource(cfg config, models *data.Models, stdin io.Reader, logger *slog.Logger) (idSource, error) {
+	parse, ok := inputFormats[cfg.inputFormat]
+	if !ok {
+		return nil, fmt.Errorf("unknown input format %q", cfg.inputFormat)
+	}
+
+	switch cfg.source {
+	case "file":
+		if cfg.inputFile == "" {
+			return nil, errors.New("-input is required with -source=file")
+		}
+		if cfg.watch {
+			return newWatchedFileSource(cfg.inputFile, parse, logger), nil
+		}
+		return newFileSource(cfg.inputFile, parse)
+	case "stdin":
+		return newReaderSource(stdin, parse)
+	case "db":
+		return dbSource{model: models.TrackedHotels}, nil
+	default:
+		return nil, fmt.Errorf("unknown source %q", cfg.source)
+	}
+}
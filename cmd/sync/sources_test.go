@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+func TestParseIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    parseFunc
+		input    string
+		expected []string
+	}{
+		{name: "comma separated", parse: parseCommaIDs, input: "1,2,3", expected: []string{"1", "2", "3"}},
+		{name: "comma with spaces and newline", parse: parseCommaIDs, input: "1, 2,\n3\n", expected: []string{"1", "2", "3"}},
+		{name: "comma trailing", parse: parseCommaIDs, input: "1,2,", expected: []string{"1", "2"}},
+		{name: "comma duplicates", parse: parseCommaIDs, input: "1,2,1", expected: []string{"1", "2"}},
+		{name: "comma empty", parse: parseCommaIDs, input: "", expected: []string{}},
+		{name: "lines", parse: parseLineIDs, input: "1\n2\r\n\n3", expected: []string{"1", "2", "3"}},
+		{name: "lines with comments", parse: parseLineIDs, input: "# hotels\n1\n  # 2\n3\n", expected: []string{"1", "3"}},
+		{name: "csv with header", parse: parseCSVIDs, input: "hotel_id,name\n1,Foo\n2,\"Bar, Baz\"\n", expected: []string{"1", "2"}},
+		{name: "csv without header", parse: parseCSVIDs, input: "1,Foo\n2,Bar\n", expected: []string{"1", "2"}},
+		{name: "csv single column", parse: parseCSVIDs, input: "1\n2\n2\n", expected: []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotels, err := tt.parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if ids := hotelIDs(hotels); !slices.Equal(ids, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func TestParseIDs_Tiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    parseFunc
+		input    string
+		expected []trackedHotel
+	}{
+		{
+			name:     "comma uses default tier",
+			parse:    parseCommaIDs,
+			input:    "1,2",
+			expected: []trackedHotel{{id: "1", tier: "default"}, {id: "2", tier: "default"}},
+		},
+		{
+			name:     "csv tier column",
+			parse:    parseCSVIDs,
+			input:    "hotel_id,name,tier\n1,Foo,hot\n2,Bar,\n3,Baz,nightly\n",
+			expected: []trackedHotel{{id: "1", tier: "hot"}, {id: "2", tier: "default"}, {id: "3", tier: "nightly"}},
+		},
+		{
+			name:     "csv short row",
+			parse:    parseCSVIDs,
+			input:    "hotel_id,Tier\n1\n2,hot\n",
+			expected: []trackedHotel{{id: "1", tier: "default"}, {id: "2", tier: "hot"}},
+		},
+		{
+			name:     "csv without tier column",
+			parse:    parseCSVIDs,
+			input:    "hotel_id,name\n1,hot\n",
+			expected: []trackedHotel{{id: "1", tier: "default"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotels, err := tt.parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !slices.Equal(hotels, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, hotels)
+			}
+		})
+	}
+}
+
+func TestParseCSVIDs_Malformed(t *testing.T) {
+	_, err := parseCSVIDs([]byte("1,\"unterminated\n"))
+	if err == nil {
+		t.Error("expected an error for malformed CSV")
+	}
+}
+
+func TestNewReaderSource(t *testing.T) {
+	source, err := newReaderSource(strings.NewReader("10\n20\n"), parseLineIDs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	hotels, err := source.Hotels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if ids := hotelIDs(hotels); !slices.Equal(ids, []string{"10", "20"}) {
+		t.Errorf("expected [10 20], got %v", ids)
+	}
+}
+
+func TestWatchedFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	writeIDs := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	source := newWatchedFileSource(path, parseCommaIDs, logger)
+	ctx := context.Background()
+
+	_, err := source.Hotels(ctx)
+	if err == nil {
+		t.Fatal("expected an error before the file exists")
+	}
+
+	start := time.Now().Add(-time.Hour)
+	writeIDs("1,2", start)
+
+	hotels, err := source.Hotels(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ids := hotelIDs(hotels); !slices.Equal(ids, []string{"1", "2"}) {
+		t.Errorf("expected [1 2], got %v", ids)
+	}
+
+	writeIDs("1,3,4", start.Add(time.Minute))
+
+	hotels, err = source.Hotels(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ids := hotelIDs(hotels); !slices.Equal(ids, []string{"1", "3", "4"}) {
+		t.Errorf("expected the file to be re-read, got %v", ids)
+	}
+
+	os.Remove(path)
+
+	hotels, err = source.Hotels(ctx)
+	if err != nil {
+		t.Fatalf("expected previous IDs to be kept, got error: %s", err)
+	}
+	if ids := hotelIDs(hotels); !slices.Equal(ids, []string{"1", "3", "4"}) {
+		t.Errorf("expected previous IDs to be kept, got %v", ids)
+	}
+}
+
+func TestDBSource(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	source := dbSource{model: data.TrackedHotelModel{DB: db}}
+
+	mock.ExpectQuery(`SELECT hotel_id, tier FROM tracked_hotels`).
+		WillReturnRows(sqlmock.NewRows([]string{"hotel_id", "tier"}).AddRow(3, "hot").AddRow(7, "default"))
+
+	hotels, err := source.Hotels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []trackedHotel{{id: "3", tier: "hot"}, {id: "7", tier: "default"}}
+	if !slices.Equal(hotels, expected) {
+		t.Errorf("expected %v, got %v", expected, hotels)
+	}
+
+	mock.ExpectQuery(`SELECT hotel_id, tier FROM tracked_hotels`).
+		WillReturnError(errors.New("connection refused"))
+
+	_, err = source.Hotels(context.Background())
+	if err == nil {
+		t.Error("expected the query error to be returned")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte("1,2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	models := &data.Models{}
+
+	tests := []struct {
+		name    string
+		cfg     config
+		wantErr bool
+		want    any
+	}{
+		{name: "file", cfg: config{source: "file", inputFile: path, inputFormat: "comma"}, want: staticSource{}},
+		{name: "watched file", cfg: config{source: "file", inputFile: path, inputFormat: "comma", watch: true}, want: &watchedFileSource{}},
+		{name: "stdin", cfg: config{source: "stdin", inputFormat: "lines"}, want: staticSource{}},
+		{name: "db", cfg: config{source: "db", inputFormat: "comma"}, want: dbSource{}},
+		{name: "file without input", cfg: config{source: "file", inputFormat: "comma"}, wantErr: true},
+		{name: "unknown source", cfg: config{source: "s3", inputFormat: "comma"}, wantErr: true},
+		{name: "unknown format", cfg: config{source: "file", inputFile: path, inputFormat: "xml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := newSource(tt.cfg, models, strings.NewReader("5\n"), logger)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			switch tt.want.(type) {
+			case staticSource:
+				_, ok := source.(staticSource)
+				if !ok {
+					t.Errorf("expected a staticSource, got %T", source)
+				}
+			case *watchedFileSource:
+				_, ok := source.(*watchedFileSource)
+				if !ok {
+					t.Errorf("expected a *watchedFileSource, got %T", source)
+				}
+			case dbSource:
+				_, ok := source.(dbSource)
+				if !ok {
+					t.Errorf("expected a dbSource, got %T", source)
+				}
+			}
+		})
+	}
+}
+
+func hotelIDs(hotels []trackedHotel) []string {
+	ids := []string{}
+	for _, hotel := range hotels {
+		ids = append(ids, hotel.id)
+	}
+	return ids
+}
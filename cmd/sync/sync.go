@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+type outcome int
+
+const (
+	outcomeSucceeded outcome = iota
+	outcomeFailed
+	outcomeSkipped
+)
+
+func (o outcome) String() string {
+	switch o {
+	case outcomeSucceeded:
+		return data.SyncOutcomeSucceeded
+	case outcomeFailed:
+		return data.SyncOutcomeFailed
+	default:
+		return data.SyncOutcomeSkipped
+	}
+}
+
+type hotelResult struct {
+	id              string
+	hotelID         int
+	outcome         outcome
+	err             error
+	reviewsInserted int
+	reviewsUpdated  int
+	reviewsRemoved  int
+	fullResync      bool
+	heldBack        bool
+	duration        time.Duration
+}
+
+type runStats struct {
+	succeeded       int
+	failed          int
+	skipped         int
+	reviewsInserted int
+	reviewsUpdated  int
+	reviewsRemoved  int
+	fullResyncs     int
+	heldBack        int
+	duration        time.Duration
+	client          clientMetrics
+}
+
+// runSync performs one pass over the ids in t. It returns false without
+// doing any work when the tier's previous pass is still in flight, so a slow
+// run is never overlapped by the next scheduler tick, or when another
+// instance holds the tier's sync lock. Failing to check the lock at all, for
+// instance because the database is down, is returned as an error.
+func (app *application) runSync(ctx context.Context, t *tier, ids []string) (runStats, bool, error) {
+	if !t.running.CompareAndSwap(false, true) {
+		app.logger.Warn("previous sync still running, skipping this run", "tier", t.name)
+		return runStats{}, false, nil
+	}
+	defer t.running.Store(false)
+
+	if t.lock != nil {
+		lockCtx, release, ok, err := t.lock.tryAcquire(ctx)
+		if err != nil {
+			return runStats{}, false, fmt.Errorf("acquiring sync lock: %w", err)
+		}
+		if !ok {
+			app.logger.Info("another instance holds the sync lock, skipping this run", "tier", t.name)
+			return runStats{}, false, nil
+		}
+		defer release()
+		ctx = lockCtx
+	}
+
+	app.logger.Info("starting sync", "tier", t.name, "hotels", len(ids), "workers", app.config.workers, "dry_run", app.config.dryRun)
+
+	// A dry run must not write anything, including its own history.
+	var run *data.SyncRun
+	if !app.config.dryRun {
+		run = &data.SyncRun{Tier: t.name, HotelsTotal: len(ids)}
+		err := app.models.SyncRuns.Insert(run)
+		if err != nil {
+			app.logger.Error("could not record sync run", "error", err.Error())
+			run = nil
+		}
+	}
+
+	failures := app.newFailureTracker()
+
+	before := app.cupid.metrics()
+	stats := runPool(ctx, ids, app.config.workers, func(ctx context.Context, id string) hotelResult {
+		result := app.syncTracked(ctx, id, failures)
+		if run != nil {
+			app.recordResult(run.ID, result)
+		}
+		return result
+	})
+	stats.client = app.cupid.metrics().sub(before)
+
+	if run != nil {
+		run.Succeeded = stats.succeeded
+		run.Failed = stats.failed
+		run.Skipped = stats.skipped
+		run.ReviewsInserted = stats.reviewsInserted
+		run.ReviewsUpdated = stats.reviewsUpdated
+		run.ReviewsRemoved = stats.reviewsRemoved
+
+		err := app.models.SyncRuns.Finish(run)
+		if err != nil {
+			app.logger.Error("could not record sync run", "run_id", run.ID, "error", err.Error())
+		}
+	}
+
+	app.logger.Info("sync finished",
+		"tier", t.name,
+		"succeeded", stats.succeeded,
+		"failed", stats.failed,
+		"skipped", stats.skipped,
+		"reviews_inserted", stats.reviewsInserted,
+		"reviews_updated", stats.reviewsUpdated,
+		"reviews_removed", stats.reviewsRemoved,
+		"full_review_resyncs", stats.fullResyncs,
+		"quarantined", stats.heldBack,
+		"duration", stats.duration.String(),
+		"cupid_requests", stats.client.Requests,
+		"rate_limit_wait", stats.client.LimiterWait.String(),
+		"rate_limit_delays", stats.client.LimiterDelays,
+	)
+
+	return stats, true, nil
+}
+
+// runPool fans ids out to at most workers concurrent calls of fn and tallies
+// the results. IDs that were never handed to a worker because ctx was
+// cancelled are counted as skipped.
+func runPool(ctx context.Context, ids []string, workers int, fn func(context.Context, string) hotelResult) runStats {
+	start := time.Now()
+
+	jobs := make(chan string)
+	results := make(chan hotelResult)
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				results <- fn(ctx, id)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, id := range ids {
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var stats runStats
+	processed := 0
+	for res := range results {
+		processed++
+		stats.reviewsInserted += res.reviewsInserted
+		stats.reviewsUpdated += res.reviewsUpdated
+		stats.reviewsRemoved += res.reviewsRemoved
+		if res.fullResync {
+			stats.fullResyncs++
+		}
+		if res.heldBack {
+			stats.heldBack++
+		}
+		switch res.outcome {
+		case outcomeSucceeded:
+			stats.succeeded++
+		case outcomeFailed:
+			stats.failed++
+		case outcomeSkipped:
+			stats.skipped++
+		}
+	}
+
+	stats.skipped += len(ids) - processed
+	stats.duration = time.Since(start)
+
+	return stats
+}
+
+// recordResult stores the outcome of a single hotel in the run history.
+// Results for IDs that could not be parsed are not recorded.
+func (app *application) recordResult(runID int64, result hotelResult) {
+	if result.hotelID == 0 {
+		return
+	}
+
+	item := &data.SyncRunItem{
+		RunID:           runID,
+		HotelID:         result.hotelID,
+		Outcome:         result.outcome.String(),
+		ReviewsInserted: result.reviewsInserted,
+		ReviewsUpdated:  result.reviewsUpdated,
+		ReviewsRemoved:  result.reviewsRemoved,
+		DurationMs:      result.duration.Milliseconds(),
+	}
+	if result.err != nil {
+		item.Error = result.err.Error()
+	}
+
+	err := app.models.SyncRuns.InsertItem(item)
+	if err != nil {
+		app.logger.Error("could not record sync result", "run_id", runID, "hotel_id", result.hotelID, "error", err.Error())
+	}
+}
+
+func (app *application) syncHotel(ctx context.Context, id string) hotelResult {
+	start := time.Now()
+	result := hotelResult{id: id}
+
+	hotelID, err := strconv.Atoi(id)
+	if err != nil || hotelID < 1 {
+		app.logger.Warn("skipping invalid hotel ID", "hotel_id", id)
+		result.outcome = outcomeSkipped
+		return result
+	}
+	result.hotelID = hotelID
+
+	fail := func(err error) hotelResult {
+		app.logger.Error(err.Error(), "hotel_id", id)
+		result.outcome = outcomeFailed
+		result.err = err
+		result.duration = time.Since(start)
+		return result
+	}
+
+	hotel, err := app.cupid.getHotel(ctx, id)
+	if err != nil {
+		return fail(fmt.Errorf("fetching hotel data: %w", err))
+	}
+
+	plan, err := app.planReviews(hotelID)
+	if err != nil {
+		return fail(fmt.Errorf("reading sync state: %w", err))
+	}
+
+	reviews, err := app.fetchReviews(ctx, id, &plan)
+	if err != nil {
+		return fail(fmt.Errorf("fetching review data: %w", err))
+	}
+	result.fullResync = plan.full
+
+	if app.config.dryRun {
+		diff, err := app.previewHotel(hotel, reviews, plan.full)
+		if err != nil {
+			return fail(fmt.Errorf("comparing with stored data: %w", err))
+		}
+		app.printDiff(diff)
+
+		result.outcome = outcomeSucceeded
+		result.reviewsInserted = len(diff.newReviews)
+		result.reviewsUpdated = len(diff.changedReviews)
+		result.reviewsRemoved = len(diff.removedReviews)
+		result.duration = time.Since(start)
+		return result
+	}
+
+	// The hotel and its reviews are written in one transaction so a failure
+	// part way through never leaves a partial review set behind. After a full
+	// fetch, stored reviews that were not part of the upserted set have
+	// disappeared or changed key upstream and are deleted, unless Cupid
+	// returned no reviews at all, which is more likely a glitch than every
+	// review being removed.
+	var batch data.BatchResult
+	var removed int
+	var resyncDue bool
+	err = data.WithTx(ctx, app.db, func(tx *sql.Tx) error {
+		err := app.writeHotel(tx, hotel)
+		if err != nil {
+			return err
+		}
+
+		batch, err = app.models.Reviews.UpsertBatchTx(tx, hotel.HotelID, reviews)
+		if err != nil {
+			return fmt.Errorf("inserting review data: %w", err)
+		}
+
+		if plan.full && len(reviews) > 0 {
+			removed, err = app.models.Reviews.DeleteExceptTx(tx, hotel.HotelID, batch.IDs)
+			if err != nil {
+				return fmt.Errorf("removing stale reviews: %w", err)
+			}
+		}
+
+		resyncDue, err = app.recordReviewSync(tx, hotel, plan)
+		if err != nil {
+			return fmt.Errorf("recording sync state: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	if resyncDue {
+		app.logger.Info("stored reviews no longer match review_count, full resync due", "hotel_id", id, "review_count", hotel.ReviewCount)
+	}
+
+	result.reviewsInserted = batch.Inserted
+	result.reviewsUpdated = batch.Updated
+	result.reviewsRemoved = removed
+	result.outcome = outcomeSucceeded
+	result.duration = time.Since(start)
+	return result
+}
+
+// writeHotel stores hotel if it is new or differs from the stored copy, and
+// records which fields changed in the hotel's change log. Unchanged hotels
+// are not written at all so updated_at only moves on real changes. Photos,
+// facilities, rooms and policies live in their own tables and are replaced
+// section by section when they differ.
+func (app *application) writeHotel(tx *sql.Tx, hotel *data.Hotel) error {
+	stored, err := app.models.Hotels.GetForUpdateTx(tx, int64(hotel.HotelID))
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return fmt.Errorf("reading stored hotel data: %w", err)
+	}
+
+	var changes []data.FieldChange
+	if stored != nil {
+		changes = stored.Diff(hotel)
+	}
+
+	if stored == nil || len(changes) > 0 {
+		_, err = app.models.Hotels.UpsertTx(tx, hotel)
+		if err != nil {
+			return fmt.Errorf("inserting hotel data: %w", err)
+		}
+
+		err = app.models.HotelChanges.InsertTx(tx, hotel.HotelID, changes)
+		if err != nil {
+			return fmt.Errorf("recording hotel changes: %w", err)
+		}
+	}
+
+	_, err = app.models.HotelDetails.ReplaceTx(tx, hotel)
+	if err != nil {
+		return fmt.Errorf("writing hotel details: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/data"
+)
+
+func newTestApplication(t *testing.T, apiUrl string) (*application, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	app := &application{
+		config: config{
+			apiKey:  "test-key",
+			apiUrl:  apiUrl,
+			workers: 1,
+		},
+		logger: logger,
+		db:     db,
+		models: data.NewModels(db),
+		cupid:  newCupidClient(apiUrl, "test-key", retryPolicy{maxAttempts: 1}, nil, logger),
+		tiers:  []*tier{{name: data.DefaultTier}},
+	}
+
+	return app, mock
+}
+
+func TestRunPool(t *testing.T) {
+	ids := []string{"1", "2", "3", "4", "5", "6"}
+
+	stats := runPool(context.Background(), ids, 3, func(ctx context.Context, id string) hotelResult {
+		switch id {
+		case "2", "4":
+			return hotelResult{id: id, outcome: outcomeFailed, err: errors.New("boom")}
+		case "6":
+			return hotelResult{id: id, outcome: outcomeSkipped}
+		}
+		return hotelResult{id: id, outcome: outcomeSucceeded}
+	})
+
+	if stats.succeeded != 3 {
+		t.Errorf("expected 3 succeeded, got %d", stats.succeeded)
+	}
+	if stats.failed != 2 {
+		t.Errorf("expected 2 failed, got %d", stats.failed)
+	}
+	if stats.skipped != 1 {
+		t.Errorf("expected 1 skipped, got %d", stats.skipped)
+	}
+	if stats.duration <= 0 {
+		t.Error("expected a positive duration")
+	}
+}
+
+func TestRunPool_BoundedConcurrency(t *testing.T) {
+	ids := make([]string, 50)
+	for i := range ids {
+		ids[i] = "1"
+	}
+
+	var inFlight, peak atomic.Int32
+
+	runPool(context.Background(), ids, 4, func(ctx context.Context, id string) hotelResult {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		inFlight.Add(-1)
+		return hotelResult{id: id, outcome: outcomeSucceeded}
+	})
+
+	if got := peak.Load(); got > 4 {
+		t.Errorf("expected at most 4 concurrent workers, got %d", got)
+	}
+	if got := peak.Load(); got < 2 {
+		t.Errorf("expected work to run in parallel, peak concurrency was %d", got)
+	}
+}
+
+func TestRunPool_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ids := []string{"1", "2", "3", "4", "5"}
+
+	stats := runPool(ctx, ids, 1, func(ctx context.Context, id string) hotelResult {
+		cancel()
+		return hotelResult{id: id, outcome: outcomeSucceeded}
+	})
+
+	if total := stats.succeeded + stats.failed + stats.skipped; total != len(ids) {
+		t.Errorf("expected every ID to be accounted for, got %d of %d", total, len(ids))
+	}
+	if stats.skipped == 0 {
+		t.Error("expected undispatched IDs to be counted as skipped")
+	}
+}
+
+func TestRunSync_SingleInFlight(t *testing.T) {
+	app, mock := newTestApplication(t, "")
+	tier := app.tiers[0]
+	tier.running.Store(true)
+
+	_, ran, _ := app.runSync(context.Background(), tier, []string{"1"})
+	if ran {
+		t.Error("expected run to be skipped while another is in flight")
+	}
+
+	tier.running.Store(false)
+
+	mock.ExpectQuery(`INSERT INTO sync_runs`).
+		WithArgs("default", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(1, time.Now()))
+	mock.ExpectQuery(`UPDATE sync_runs`).
+		WithArgs(0, 0, 1, 0, 0, 0, int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(time.Now()))
+
+	stats, ran, _ := app.runSync(context.Background(), tier, []string{"not-a-number"})
+	if !ran {
+		t.Fatal("expected run to proceed once the previous one finished")
+	}
+	if stats.skipped != 1 {
+		t.Errorf("expected invalid ID to be skipped, got %+v", stats)
+	}
+	if tier.running.Load() {
+		t.Error("expected running flag to be cleared after the run")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRunSync_RecordsHistory(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.0/property/123":
+			io.WriteString(w, `{"hotel_id": 123, "hotel_name": "Test Hotel"}`)
+		case "/v3.0/property/reviews/123/1000000":
+			io.WriteString(w, `[{"name": "Jane", "headline": "Lovely"}, {"name": "John", "headline": "Fine"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cupid.Close()
+
+	app, mock := newTestApplication(t, cupid.URL)
+
+	mock.ExpectQuery(`INSERT INTO sync_runs`).
+		WithArgs("default", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(7, time.Now()))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+		WithArgs(int64(123)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO hotels`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	mock.ExpectQuery(`INSERT INTO reviews`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "inserted"}).AddRow(1, true).AddRow(2, false))
+	mock.ExpectExec(`DELETE FROM reviews`).
+		WithArgs(123, "{1,2}").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`INSERT INTO sync_run_items`).
+		WithArgs(int64(7), 123, "succeeded", "", 1, 1, 3, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+
+	mock.ExpectQuery(`INSERT INTO sync_run_items`).
+		WithArgs(int64(7), 404, "failed", sqlmock.AnyArg(), 0, 0, 0, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+
+	mock.ExpectQuery(`UPDATE sync_runs`).
+		WithArgs(1, 1, 0, 1, 1, 3, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(time.Now()))
+
+	stats, ran, _ := app.runSync(context.Background(), app.tiers[0], []string{"123", "404"})
+	if !ran {
+		t.Fatal("expected run to proceed")
+	}
+
+	if stats.succeeded != 1 || stats.failed != 1 {
+		t.Errorf("expected 1 success and 1 failure, got %+v", stats)
+	}
+	if stats.reviewsInserted != 1 || stats.reviewsUpdated != 1 || stats.reviewsRemoved != 3 {
+		t.Errorf("expected 1 inserted, 1 updated and 3 removed reviews, got %+v", stats)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncHotel_RollsBackOnReviewFailure(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.0/property/123":
+			io.WriteString(w, `{"hotel_id": 123, "hotel_name": "Test Hotel"}`)
+		case "/v3.0/property/reviews/123/1000000":
+			io.WriteString(w, `[{"name": "Jane", "headline": "Lovely"}, {"name": "John", "headline": "Fine"}, {"name": "Joe", "headline": "Meh"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cupid.Close()
+
+	app, mock := newTestApplication(t, cupid.URL)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+		WithArgs(int64(123)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO hotels`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	mock.ExpectQuery(`INSERT INTO reviews`).
+		WillReturnError(errors.New("value too long for type character varying"))
+	mock.ExpectRollback()
+
+	result := app.syncHotel(context.Background(), "123")
+
+	if result.outcome != outcomeFailed {
+		t.Fatalf("expected the hotel to fail, got %v", result.outcome)
+	}
+	if result.err == nil || !strings.Contains(result.err.Error(), "inserting review data") {
+		t.Errorf("expected a review data error, got %v", result.err)
+	}
+	if result.reviewsInserted != 0 || result.reviewsUpdated != 0 {
+		t.Errorf("expected no reviews to be counted after a rollback, got %d inserted and %d updated", result.reviewsInserted, result.reviewsUpdated)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncHotel_KeepsReviewsWhenNoneFetched(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.0/property/123":
+			io.WriteString(w, `{"hotel_id": 123, "hotel_name": "Test Hotel"}`)
+		case "/v3.0/property/reviews/123/1000000":
+			io.WriteString(w, `[]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cupid.Close()
+
+	app, mock := newTestApplication(t, cupid.URL)
+
+	// No DELETE expectation: an empty upstream set must not wipe the
+	// stored reviews.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+		WithArgs(int64(123)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO hotels`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	mock.ExpectCommit()
+
+	result := app.syncHotel(context.Background(), "123")
+
+	if result.outcome != outcomeSucceeded {
+		t.Fatalf("expected the hotel to succeed, got %v: %v", result.outcome, result.err)
+	}
+	if result.reviewsRemoved != 0 {
+		t.Errorf("expected no reviews to be removed, got %d", result.reviewsRemoved)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncHotel_RecordsChanges(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.0/property/123":
+			io.WriteString(w, `{"hotel_id": 123, "hotel_name": "Grand Hotel", "stars": 5}`)
+		case "/v3.0/property/reviews/123/1000000":
+			io.WriteString(w, `[]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cupid.Close()
+
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		stored    []driver.Value
+		expectSQL func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:   "changed fields are written and logged",
+			stored: []driver.Value{123, "", "Old Hotel", "", "", "", "", "", "", "", 4, 0.0, 0, false, false, "", nil, nil, now, now},
+			expectSQL: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO hotels`).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+				mock.ExpectExec(`INSERT INTO hotel_changes \(hotel_id, field, old_value, new_value\) VALUES \(\$1, \$2, \$3, \$4\), \(\$5, \$6, \$7, \$8\)`).
+					WithArgs(123, "hotel_name", "Old Hotel", "Grand Hotel", 123, "stars", "4", "5").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			name:      "unchanged hotel is not written",
+			stored:    []driver.Value{123, "", "Grand Hotel", "", "", "", "", "", "", "", 5, 0.0, 0, false, false, "", nil, nil, now, now},
+			expectSQL: func(mock sqlmock.Sqlmock) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, mock := newTestApplication(t, cupid.URL)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+				WithArgs(int64(123)).
+				WillReturnRows(sqlmock.NewRows(hotelColumns).AddRow(tt.stored...))
+			tt.expectSQL(mock)
+			mock.ExpectCommit()
+
+			result := app.syncHotel(context.Background(), "123")
+
+			if result.outcome != outcomeSucceeded {
+				t.Fatalf("expected the hotel to succeed, got %v: %v", result.outcome, result.err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSyncHotel_ReplacesChangedDetails(t *testing.T) {
+	cupid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.0/property/123":
+			io.WriteString(w, `{
+				"hotel_id": 123, "hotel_name": "Grand Hotel", "stars": 5,
+				"photos": [{"url": "https://img.example.com/new.jpg", "main_photo": true}],
+				"checkin": {"checkin_start": "14:00", "checkin_end": "23:00", "checkout": "11:00"}
+			}`)
+		case "/v3.0/property/reviews/123/1000000":
+			io.WriteString(w, `[]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cupid.Close()
+
+	app, mock := newTestApplication(t, cupid.URL)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+		WithArgs(int64(123)).
+		WillReturnRows(sqlmock.NewRows(hotelColumns).AddRow(123, "", "Grand Hotel", "", "", "", "", "", "", "", 5, 0.0, 0, false, false, "", nil, nil, now, now))
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_photos WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows([]string{"url", "hd_url", "description", "class", "main_photo", "score"}).
+			AddRow("https://img.example.com/old.jpg", "", "", "", true, 0.0))
+	// The check-in times are unchanged, so the policies are left alone.
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_policies WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows([]string{"policy_type", "name", "description"}).
+			AddRow("checkin", "Check-in", "From 14:00 to 23:00").
+			AddRow("checkout", "Check-out", "Until 11:00"))
+	mock.ExpectExec(`DELETE FROM hotel_photos WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO hotel_photos (.+) FROM unnest`).
+		WithArgs(123, "{\"https://img.example.com/new.jpg\"}", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "{t}", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := app.syncHotel(context.Background(), "123")
+
+	if result.outcome != outcomeSucceeded {
+		t.Fatalf("expected the hotel to succeed, got %v: %v", result.outcome, result.err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
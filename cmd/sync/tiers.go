@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/JLL32/nuitee/internal/data"
+	"github.com/go-co-op/gocron"
+)
+
+// tier is a group of hotels refreshed on its own schedule, e.g. popular
+// hotels every 15 minutes and the long tail nightly. Each tier has its own
+// in-flight guard and sync lock, so a slow nightly run never holds up the
+// frequent ones.
+type tier struct {
+	name string
+	// cron is the tier's cron expression. An empty expression, only allowed
+	// for the default tier, means every -interval minutes.
+	cron    string
+	running atomic.Bool
+	lock    syncLock
+}
+
+// tierSchedules maps tier names to cron expressions. It implements
+// flag.Value so -tier can be repeated.
+type tierSchedules map[string]string
+
+func (ts tierSchedules) String() string {
+	var pairs []string
+	for _, name := range slices.Sorted(maps.Keys(ts)) {
+		pairs = append(pairs, name+"="+ts[name])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (ts tierSchedules) Set(value string) error {
+	name, expr, ok := strings.Cut(value, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || name == "" || expr == "" {
+		return fmt.Errorf("expected name=cron-expression, got %q", value)
+	}
+
+	ts[name] = expr
+	return nil
+}
+
+// newTiers builds the default tier followed by the tiers named with -tier in
+// alphabetical order. Each tier gets its own advisory lock: the default tier
+// uses -lock-key itself so it stays compatible with instances that predate
+// tiers, the others a key derived from it and the tier name. A dry run writes
+// nothing, so it takes no locks and can run alongside a real sync.
+func newTiers(cfg config, db *sql.DB, logger *slog.Logger) []*tier {
+	schedules := maps.Clone(cfg.tiers)
+	if schedules == nil {
+		schedules = tierSchedules{}
+	}
+	if cfg.cron != "" {
+		schedules[data.DefaultTier] = cfg.cron
+	}
+
+	tiers := []*tier{{name: data.DefaultTier, cron: schedules[data.DefaultTier]}}
+	for _, name := range slices.Sorted(maps.Keys(schedules)) {
+		if name != data.DefaultTier {
+			tiers = append(tiers, &tier{name: name, cron: schedules[name]})
+		}
+	}
+
+	if !cfg.dryRun {
+		for _, t := range tiers {
+			t.lock = newAdvisoryLock(db, tierLockKey(cfg.lockKey, t.name), logger)
+		}
+	}
+
+	return tiers
+}
+
+func tierLockKey(base int64, name string) int64 {
+	if name == data.DefaultTier {
+		return base
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	return base ^ int64(h.Sum64())
+}
+
+// schedule registers a job on s that syncs t on its schedule.
+func (app *application) schedule(s *gocron.Scheduler, t *tier) error {
+	job := s.Every(app.config.interval).Minutes()
+	if t.cron != "" {
+		job = s.Cron(t.cron)
+	}
+
+	_, err := job.Do(func() {
+		_, err := app.syncTier(context.Background(), t)
+		if err != nil {
+			app.logger.Error(err.Error(), "tier", t.name)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("scheduling tier %q: %w", t.name, err)
+	}
+
+	app.logger.Info("scheduled tier", "tier", t.name, "cron", t.cron, "interval", app.config.interval)
+	return nil
+}
+
+// syncTier loads the current hotels from the configured source and runs a
+// sync pass over those in t. Hotels assigned to a tier that has no schedule
+// are synced with the default tier.
+func (app *application) syncTier(ctx context.Context, t *tier) (runStats, error) {
+	hotels, err := app.source.Hotels(ctx)
+	if err != nil {
+		return runStats{}, fmt.Errorf("loading hotel IDs: %w", err)
+	}
+
+	ids := []string{}
+	unscheduled := map[string]int{}
+
+	for _, hotel := range hotels {
+		name := hotel.tier
+		if !slices.ContainsFunc(app.tiers, func(t *tier) bool { return t.name == name }) {
+			unscheduled[name]++
+			name = data.DefaultTier
+		}
+		if name == t.name {
+			ids = append(ids, hotel.id)
+		}
+	}
+
+	if t.name == data.DefaultTier {
+		for name, count := range unscheduled {
+			app.logger.Warn("tier has no schedule, syncing its hotels with the default tier", "tier", name, "hotels", count)
+		}
+	}
+
+	stats, _, err := app.runSync(ctx, t, ids)
+
+	return stats, err
+}
+
+// syncAllTiers runs one pass over every tier in turn, stopping early if ctx
+// is cancelled, and returns the combined stats.
+func (app *application) syncAllTiers(ctx context.Context) (runStats, error) {
+	var total runStats
+
+	for _, t := range app.tiers {
+		if ctx.Err() != nil {
+			break
+		}
+
+		stats, err := app.syncTier(ctx, t)
+		if err != nil {
+			return total, err
+		}
+
+		total.succeeded += stats.succeeded
+		total.failed += stats.failed
+		total.skipped += stats.skipped
+		total.reviewsInserted += stats.reviewsInserted
+		total.reviewsUpdated += stats.reviewsUpdated
+		total.reviewsRemoved += stats.reviewsRemoved
+		total.duration += stats.duration
+	}
+
+	return total, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTierSchedules_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "valid", value: "hot=*/15 * * * *"},
+		{name: "spaces around name", value: " nightly = 0 3 * * *"},
+		{name: "missing separator", value: "hot", wantErr: true},
+		{name: "missing name", value: "=*/15 * * * *", wantErr: true},
+		{name: "missing expression", value: "hot=", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := tierSchedules{}
+			err := ts.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error to be %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	ts := tierSchedules{}
+	ts.Set("nightly=0 3 * * *")
+	ts.Set(" hot = */15 * * * * ")
+
+	if got := ts.String(); got != "hot=*/15 * * * *,nightly=0 3 * * *" {
+		t.Errorf("unexpected String(): %q", got)
+	}
+}
+
+func TestNewTiers(t *testing.T) {
+	cfg := config{
+		cron:    "0 * * * *",
+		tiers:   tierSchedules{"nightly": "0 3 * * *", "hot": "*/15 * * * *"},
+		lockKey: defaultLockKey,
+	}
+
+	tiers := newTiers(cfg, nil, nil)
+
+	expected := []struct{ name, cron string }{
+		{"default", "0 * * * *"},
+		{"hot", "*/15 * * * *"},
+		{"nightly", "0 3 * * *"},
+	}
+	if len(tiers) != len(expected) {
+		t.Fatalf("expected %d tiers, got %d", len(expected), len(tiers))
+	}
+
+	keys := map[int64]bool{}
+	for i, want := range expected {
+		if tiers[i].name != want.name || tiers[i].cron != want.cron {
+			t.Errorf("tier %d: expected %s=%q, got %s=%q", i, want.name, want.cron, tiers[i].name, tiers[i].cron)
+		}
+
+		lock, ok := tiers[i].lock.(*advisoryLock)
+		if !ok {
+			t.Fatalf("tier %d: expected an advisory lock, got %T", i, tiers[i].lock)
+		}
+		keys[lock.key] = true
+	}
+
+	if !keys[defaultLockKey] {
+		t.Error("expected the default tier to use -lock-key")
+	}
+	if len(keys) != len(tiers) {
+		t.Errorf("expected a distinct lock key per tier, got %v", keys)
+	}
+
+	cfg.dryRun = true
+	for _, tier := range newTiers(cfg, nil, nil) {
+		if tier.lock != nil {
+			t.Errorf("expected no lock for tier %s in a dry run", tier.name)
+		}
+	}
+}
+
+func TestSyncTier(t *testing.T) {
+	app, mock := newTestApplication(t, "")
+	app.tiers = append(app.tiers, &tier{name: "hot", cron: "*/15 * * * *"})
+	app.source = staticSource{
+		{id: "a", tier: "hot"},
+		{id: "b", tier: "default"},
+		{id: "c", tier: "unscheduled"},
+		{id: "d", tier: "hot"},
+	}
+
+	tests := []struct {
+		tier  *tier
+		total int
+	}{
+		{tier: app.tiers[0], total: 2},
+		{tier: app.tiers[1], total: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tier.name, func(t *testing.T) {
+			mock.ExpectQuery(`INSERT INTO sync_runs`).
+				WithArgs(tt.tier.name, tt.total).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(1, time.Now()))
+			mock.ExpectQuery(`UPDATE sync_runs`).
+				WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(time.Now()))
+
+			// The IDs are not numeric, so every hotel is skipped without a
+			// request to Cupid.
+			stats, err := app.syncTier(context.Background(), tt.tier)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if stats.skipped != tt.total {
+				t.Errorf("expected %d hotels in tier %s, got %+v", tt.total, tt.tier.name, stats)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSyncTier_Error(t *testing.T) {
+	app, _ := newTestApplication(t, "")
+	app.source = failingSource{}
+
+	_, err := app.syncTier(context.Background(), app.tiers[0])
+	if err == nil {
+		t.Error("expected the source error to be returned")
+	}
+
+	_, err = app.syncAllTiers(context.Background())
+	if err == nil {
+		t.Error("expected the source error to be returned")
+	}
+}
+
+func TestSyncTier_LockError(t *testing.T) {
+	app, _ := newTestApplication(t, "")
+	app.source = staticSource{{id: "1", tier: app.tiers[0].name}}
+	app.tiers[0].lock = &fakeLock{err: errors.New("connection refused")}
+
+	// -once must exit non-zero when the database cannot even be asked for
+	// the lock, rather than report a pass that synced nothing.
+	_, err := app.syncAllTiers(context.Background())
+	if err == nil {
+		t.Error("expected the lock error to be returned")
+	}
+}
+
+type failingSource struct{}
+
+func (failingSource) Hotels(ctx context.Context) ([]trackedHotel, error) {
+	return nil, errors.New("source unavailable")
+}
@@ -0,0 +1,221 @@
+// Package cupidmock serves a stand-in for the Cupid property API from
+// fixture files, so the sync job can be run and tested without an API key or
+// network access. Faults such as latency, rate limiting, server errors and
+// malformed payloads can be injected to exercise the client's retry paths.
+//
+// Fixtures are read from an fs.FS laid out as:
+//
+//	property/<hotel_id>.json   the body of GET /v3.0/property/<hotel_id>
+//	reviews/<hotel_id>.json    a JSON array of reviews, newest first
+//
+// GET /v3.0/property/reviews/<hotel_id>/<count> returns the first count
+// reviews of the hotel, or an empty array when it has no reviews fixture.
+// Hotels without a property fixture are answered with 404.
+package cupidmock
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config controls the faults the handler injects. Rates are probabilities
+// between 0 and 1 and are applied in order: rate limiting first, then server
+// errors, then malformed bodies.
+type Config struct {
+	// APIKey, when set, must be sent in the x-api-key header or the request
+	// is answered with 401.
+	APIKey string
+	// Latency is added to every response.
+	Latency time.Duration
+	// RateLimitRate is the share of requests answered with 429.
+	RateLimitRate float64
+	// RetryAfter is sent with 429 responses when positive.
+	RetryAfter time.Duration
+	// ErrorRate is the share of requests answered with 500.
+	ErrorRate float64
+	// MalformedRate is the share of successful responses whose JSON body is
+	// cut short.
+	MalformedRate float64
+	// Seed makes the injected faults reproducible. Zero picks a random seed.
+	Seed uint64
+}
+
+// Handler is an http.Handler serving the Cupid endpoints the sync job uses.
+type Handler struct {
+	fixtures fs.FS
+	cfg      Config
+	mux      *http.ServeMux
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	requests atomic.Int64
+}
+
+// New returns a handler serving fixtures with the faults in cfg.
+func New(fixtures fs.FS, cfg Config) *Handler {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	h := &Handler{
+		fixtures: fixtures,
+		cfg:      cfg,
+		mux:      http.NewServeMux(),
+		rand:     rand.New(rand.NewPCG(seed, seed)),
+	}
+
+	h.mux.HandleFunc("GET /v3.0/property/{id}", h.property)
+	h.mux.HandleFunc("GET /v3.0/property/reviews/{id}/{count}", h.reviews)
+
+	return h
+}
+
+// Requests returns the number of requests served so far, faults included.
+func (h *Handler) Requests() int64 {
+	return h.requests.Load()
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests.Add(1)
+
+	if h.cfg.Latency > 0 {
+		select {
+		case <-time.After(h.cfg.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if h.cfg.APIKey != "" && r.Header.Get("x-api-key") != h.cfg.APIKey {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+
+	if h.roll(h.cfg.RateLimitRate) {
+		if h.cfg.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(h.cfg.RetryAfter.Seconds())))
+		}
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+
+	if h.roll(h.cfg.ErrorRate) {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) property(w http.ResponseWriter, r *http.Request) {
+	id, ok := hotelID(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "property not found")
+		return
+	}
+
+	b, err := fs.ReadFile(h.fixtures, "property/"+id+".json")
+	if err != nil {
+		h.fixtureError(w, err, "property not found")
+		return
+	}
+
+	h.writeJSON(w, b)
+}
+
+func (h *Handler) reviews(w http.ResponseWriter, r *http.Request) {
+	id, ok := hotelID(r)
+	count, err := strconv.Atoi(r.PathValue("count"))
+	if !ok || err != nil || count < 0 {
+		writeError(w, http.StatusNotFound, "property not found")
+		return
+	}
+
+	if _, err := fs.Stat(h.fixtures, "property/"+id+".json"); err != nil {
+		h.fixtureError(w, err, "property not found")
+		return
+	}
+
+	reviews := []json.RawMessage{}
+
+	b, err := fs.ReadFile(h.fixtures, "reviews/"+id+".json")
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	default:
+		err = json.Unmarshal(b, &reviews)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "invalid reviews fixture: "+err.Error())
+			return
+		}
+	}
+
+	b, err = json.Marshal(reviews[:min(count, len(reviews))])
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJSON(w, b)
+}
+
+// writeJSON writes b, cutting it in half when a malformed body is due.
+func (h *Handler) writeJSON(w http.ResponseWriter, b []byte) {
+	if h.roll(h.cfg.MalformedRate) {
+		b = b[:len(b)/2]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func (h *Handler) fixtureError(w http.ResponseWriter, err error, notFound string) {
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, notFound)
+		return
+	}
+
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func (h *Handler) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.rand.Float64() < rate
+}
+
+// hotelID returns the hotel ID path value if it is a positive number, which
+// also keeps it from naming anything outside the fixture directories.
+func hotelID(r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 1 {
+		return "", false
+	}
+
+	return strconv.Itoa(n), true
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	b, _ := json.Marshal(map[string]string{"error": message})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
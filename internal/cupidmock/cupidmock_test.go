@@ -0,0 +1,133 @@
+package cupidmock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testFixtures = fstest.MapFS{
+	"property/1.json": {Data: []byte(`{"hotel_id": 1, "hotel_name": "Harbour View Hotel"}`)},
+	"property/2.json": {Data: []byte(`{"hotel_id": 2, "hotel_name": "Airport Express Inn"}`)},
+	"reviews/1.json":  {Data: []byte(`[{"name": "Emma"}, {"name": "Julien"}, {"name": "Lukas"}]`)},
+}
+
+func serve(h http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestHandler_Fixtures(t *testing.T) {
+	h := New(testFixtures, Config{})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedLen    int
+	}{
+		{name: "property", path: "/v3.0/property/1", expectedStatus: http.StatusOK},
+		{name: "unknown property", path: "/v3.0/property/3", expectedStatus: http.StatusNotFound},
+		{name: "invalid id", path: "/v3.0/property/abc", expectedStatus: http.StatusNotFound},
+		{name: "reviews", path: "/v3.0/property/reviews/1/2", expectedStatus: http.StatusOK, expectedLen: 2},
+		{name: "reviews past the end", path: "/v3.0/property/reviews/1/100", expectedStatus: http.StatusOK, expectedLen: 3},
+		{name: "no reviews fixture", path: "/v3.0/property/reviews/2/10", expectedStatus: http.StatusOK, expectedLen: 0},
+		{name: "reviews of unknown property", path: "/v3.0/property/reviews/3/10", expectedStatus: http.StatusNotFound},
+		{name: "invalid count", path: "/v3.0/property/reviews/1/-1", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(h, tt.path, nil)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var body any
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if reviews, ok := body.([]any); ok && len(reviews) != tt.expectedLen {
+				t.Errorf("expected %d reviews, got %d", tt.expectedLen, len(reviews))
+			}
+		})
+	}
+
+	if h.Requests() != int64(len(tests)) {
+		t.Errorf("expected %d requests to be counted, got %d", len(tests), h.Requests())
+	}
+}
+
+func TestHandler_APIKey(t *testing.T) {
+	h := New(testFixtures, Config{APIKey: "secret"})
+
+	if rr := serve(h, "/v3.0/property/1", nil); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %v", rr.Code)
+	}
+
+	rr := serve(h, "/v3.0/property/1", http.Header{"X-Api-Key": {"secret"}})
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 with the key, got %v", rr.Code)
+	}
+}
+
+func TestHandler_Faults(t *testing.T) {
+	t.Run("rate limited", func(t *testing.T) {
+		h := New(testFixtures, Config{RateLimitRate: 1, RetryAfter: 3 * time.Second})
+
+		rr := serve(h, "/v3.0/property/1", nil)
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected 429, got %v", rr.Code)
+		}
+		if rr.Header().Get("Retry-After") != "3" {
+			t.Errorf("expected Retry-After 3, got %q", rr.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		h := New(testFixtures, Config{ErrorRate: 1})
+
+		if rr := serve(h, "/v3.0/property/1", nil); rr.Code != http.StatusInternalServerError {
+			t.Errorf("expected 500, got %v", rr.Code)
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		h := New(testFixtures, Config{MalformedRate: 1})
+
+		rr := serve(h, "/v3.0/property/reviews/1/3", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %v", rr.Code)
+		}
+		if json.Valid(rr.Body.Bytes()) {
+			t.Errorf("expected a malformed body, got %s", rr.Body)
+		}
+	})
+
+	t.Run("seeded faults repeat", func(t *testing.T) {
+		cfg := Config{ErrorRate: 0.5, Seed: 42}
+		a, b := New(testFixtures, cfg), New(testFixtures, cfg)
+
+		for i := range 20 {
+			codeA := serve(a, "/v3.0/property/1", nil).Code
+			codeB := serve(b, "/v3.0/property/1", nil).Code
+			if codeA != codeB {
+				t.Fatalf("request %d: expected the same outcome for the same seed, got %v and %v", i, codeA, codeB)
+			}
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HotelChange is one field of a hotel that changed during a sync. Changes
+// written by the same sync share a ChangedAt.
+type HotelChange struct {
+	ID      int64 `json:"id"`
+	HotelID int   `json:"hotel_id"`
+	FieldChange
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type HotelChangeModel struct {
+	DB *sql.DB
+}
+
+// InsertTx records changes for a hotel as part of tx.
+func (m HotelChangeModel) InsertTx(tx *sql.Tx, hotelID int, changes []FieldChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var values strings.Builder
+	args := make([]any, 0, len(changes)*4)
+
+	for i, change := range changes {
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		fmt.Fprintf(&values, "($%d, $%d, $%d, $%d)", i*4+1, i*4+2, i*4+3, i*4+4)
+		args = append(args, hotelID, change.Field, change.OldValue, change.NewValue)
+	}
+
+	query := `
+		INSERT INTO hotel_changes (hotel_id, field, old_value, new_value)
+		VALUES ` + values.String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// GetAllForHotel returns the recorded changes of a hotel, optionally
+// narrowed down to a single field.
+func (m HotelChangeModel) GetAllForHotel(hotelID int64, field string, filters Filters) ([]*HotelChange, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, hotel_id, field, old_value, new_value, changed_at
+		FROM hotel_changes
+		WHERE hotel_id = $1 AND (field = $2 OR $2 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, hotelID, field, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	changes := []*HotelChange{}
+
+	for rows.Next() {
+		var change HotelChange
+
+		err := rows.Scan(
+			&totalRecords,
+			&change.ID,
+			&change.HotelID,
+			&change.Field,
+			&change.OldValue,
+			&change.NewValue,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		changes = append(changes, &change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+
+	return changes, metadata, nil
+}
@@ -0,0 +1,101 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHotelChangeModel_InsertTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := HotelChangeModel{DB: db}
+
+	changes := []FieldChange{
+		{Field: "hotel_name", OldValue: "Old", NewValue: "New"},
+		{Field: "stars", OldValue: "3", NewValue: "4"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO hotel_changes \(hotel_id, field, old_value, new_value\) VALUES \(\$1, \$2, \$3, \$4\), \(\$5, \$6, \$7, \$8\)`).
+		WithArgs(123, "hotel_name", "Old", "New", 123, "stars", "3", "4").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = model.InsertTx(tx, 123, changes)
+	if err != nil {
+		t.Errorf("error was not expected while inserting hotel changes: %s", err)
+	}
+
+	// Nothing to record means no statement at all.
+	err = model.InsertTx(tx, 123, nil)
+	if err != nil {
+		t.Errorf("error was not expected for an empty change set: %s", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHotelChangeModel_GetAllForHotel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := HotelChangeModel{DB: db}
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "-changed_at",
+		SortSafelist: []string{"changed_at", "-changed_at"},
+	}
+
+	changedAt := time.Now()
+
+	rows := sqlmock.NewRows([]string{"count", "id", "hotel_id", "field", "old_value", "new_value", "changed_at"}).
+		AddRow(2, 2, 123, "stars", "3", "4", changedAt).
+		AddRow(2, 1, 123, "hotel_name", "Old", "New", changedAt)
+
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, field, old_value, new_value, changed_at FROM hotel_changes WHERE hotel_id = \$1 AND \(field = \$2 OR \$2 = ''\) ORDER BY changed_at DESC, id ASC LIMIT \$3 OFFSET \$4`).
+		WithArgs(int64(123), "", 20, 0).
+		WillReturnRows(rows)
+
+	changes, metadata, err := model.GetAllForHotel(123, "", filters)
+	if err != nil {
+		t.Fatalf("error was not expected while getting hotel changes: %s", err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	if changes[0].Field != "stars" || changes[0].OldValue != "3" || changes[0].NewValue != "4" {
+		t.Errorf("unexpected change: %+v", changes[0])
+	}
+
+	if metadata.TotalRecords != 2 {
+		t.Errorf("expected TotalRecords to be 2, got %d", metadata.TotalRecords)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
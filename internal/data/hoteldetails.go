@@ -0,0 +1,425 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// The detail sections of a hotel, stored in their own tables and only loaded
+// when asked for.
+const (
+	SectionPhotos     = "photos"
+	SectionFacilities = "facilities"
+	SectionRooms      = "rooms"
+	SectionPolicies   = "policies"
+)
+
+var HotelDetailSections = []string{SectionPhotos, SectionFacilities, SectionRooms, SectionPolicies}
+
+type HotelPhoto struct {
+	URL         string  `json:"url"`
+	HDURL       string  `json:"hd_url"`
+	Description string  `json:"image_description"`
+	Class       string  `json:"image_class1"`
+	MainPhoto   bool    `json:"main_photo"`
+	Score       float64 `json:"score"`
+}
+
+type HotelFacility struct {
+	FacilityID int    `json:"facility_id"`
+	Name       string `json:"name"`
+}
+
+type Room struct {
+	ID             int64   `json:"id"`
+	RoomName       string  `json:"room_name"`
+	Description    string  `json:"description"`
+	RoomSizeSquare float64 `json:"room_size_square"`
+	RoomSizeUnit   string  `json:"room_size_unit"`
+	MaxAdults      int     `json:"max_adults"`
+	MaxChildren    int     `json:"max_children"`
+	MaxOccupancy   int     `json:"max_occupancy"`
+}
+
+// HotelPolicy is a house rule of a hotel. Check-in and check-out times are
+// stored as policies of type checkin and checkout.
+type HotelPolicy struct {
+	PolicyType  string `json:"policy_type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type HotelDetailsModel struct {
+	DB *sql.DB
+}
+
+// Load fills the given detail sections of hotel, in the order Cupid returned
+// their items. Loaded sections are never nil, so an empty section is
+// rendered as [] rather than left out.
+func (m HotelDetailsModel) Load(hotel *Hotel, sections ...string) error {
+	return loadHotelDetails(m.DB, hotel, sections)
+}
+
+func loadHotelDetails(q Querier, hotel *Hotel, sections []string) error {
+	var err error
+
+	for _, section := range sections {
+		switch section {
+		case SectionPhotos:
+			hotel.Photos, err = getHotelPhotos(q, hotel.HotelID)
+		case SectionFacilities:
+			hotel.Facilities, err = getHotelFacilities(q, hotel.HotelID)
+		case SectionRooms:
+			hotel.Rooms, err = getRooms(q, hotel.HotelID)
+		case SectionPolicies:
+			hotel.Policies, err = getHotelPolicies(q, hotel.HotelID)
+		default:
+			err = fmt.Errorf("unknown hotel detail section %q", section)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Diff returns the names of the detail sections of hotel that differ from
+// what is stored. Nil sections were not part of the payload and are never
+// reported.
+func (m HotelDetailsModel) Diff(hotel *Hotel) ([]string, error) {
+	return diffHotelDetails(m.DB, hotel)
+}
+
+// ReplaceTx stores the detail sections of hotel as part of tx and returns
+// the names of the sections that changed. A section that differs from the
+// stored one is replaced as a whole. Nil sections are left as they are,
+// while empty ones clear what is stored.
+func (m HotelDetailsModel) ReplaceTx(tx *sql.Tx, hotel *Hotel) ([]string, error) {
+	changed, err := diffHotelDetails(tx, hotel)
+	if err != nil {
+		return nil, fmt.Errorf("reading stored hotel details: %w", err)
+	}
+
+	for _, section := range changed {
+		var err error
+
+		switch section {
+		case SectionPhotos:
+			err = replaceHotelPhotos(tx, hotel)
+		case SectionFacilities:
+			err = replaceHotelFacilities(tx, hotel)
+		case SectionRooms:
+			err = replaceRooms(tx, hotel)
+		case SectionPolicies:
+			err = replaceHotelPolicies(tx, hotel)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("replacing hotel %s: %w", section, err)
+		}
+	}
+
+	return changed, nil
+}
+
+func diffHotelDetails(q Querier, hotel *Hotel) ([]string, error) {
+	stored := Hotel{HotelID: hotel.HotelID}
+
+	var sections []string
+	for _, section := range HotelDetailSections {
+		if hotel.hasSection(section) {
+			sections = append(sections, section)
+		}
+	}
+
+	err := loadHotelDetails(q, &stored, sections)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, section := range sections {
+		var equal bool
+
+		switch section {
+		case SectionPhotos:
+			equal = slices.Equal(stored.Photos, hotel.Photos)
+		case SectionFacilities:
+			equal = slices.Equal(stored.Facilities, hotel.Facilities)
+		case SectionRooms:
+			equal = slices.Equal(stored.Rooms, hotel.Rooms)
+		case SectionPolicies:
+			equal = slices.Equal(stored.Policies, hotel.Policies)
+		}
+		if !equal {
+			changed = append(changed, section)
+		}
+	}
+
+	return changed, nil
+}
+
+func (h *Hotel) hasSection(section string) bool {
+	switch section {
+	case SectionPhotos:
+		return h.Photos != nil
+	case SectionFacilities:
+		return h.Facilities != nil
+	case SectionRooms:
+		return h.Rooms != nil
+	case SectionPolicies:
+		return h.Policies != nil
+	default:
+		return false
+	}
+}
+
+func getHotelPhotos(q Querier, hotelID int) ([]HotelPhoto, error) {
+	query := `
+		SELECT url, hd_url, description, class, main_photo, score
+		FROM hotel_photos
+		WHERE hotel_id = $1
+		ORDER BY position`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.QueryContext(ctx, query, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []HotelPhoto{}
+	for rows.Next() {
+		var photo HotelPhoto
+
+		err := rows.Scan(&photo.URL, &photo.HDURL, &photo.Description, &photo.Class, &photo.MainPhoto, &photo.Score)
+		if err != nil {
+			return nil, err
+		}
+
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+func getHotelFacilities(q Querier, hotelID int) ([]HotelFacility, error) {
+	query := `
+		SELECT facility_id, name
+		FROM hotel_facilities
+		WHERE hotel_id = $1
+		ORDER BY position`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.QueryContext(ctx, query, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facilities := []HotelFacility{}
+	for rows.Next() {
+		var facility HotelFacility
+
+		err := rows.Scan(&facility.FacilityID, &facility.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		facilities = append(facilities, facility)
+	}
+
+	return facilities, rows.Err()
+}
+
+func getRooms(q Querier, hotelID int) ([]Room, error) {
+	query := `
+		SELECT room_id, room_name, description, room_size_square, room_size_unit, max_adults, max_children, max_occupancy
+		FROM rooms
+		WHERE hotel_id = $1
+		ORDER BY position`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.QueryContext(ctx, query, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rooms := []Room{}
+	for rows.Next() {
+		var room Room
+
+		err := rows.Scan(
+			&room.ID,
+			&room.RoomName,
+			&room.Description,
+			&room.RoomSizeSquare,
+			&room.RoomSizeUnit,
+			&room.MaxAdults,
+			&room.MaxChildren,
+			&room.MaxOccupancy,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rooms = append(rooms, room)
+	}
+
+	return rooms, rows.Err()
+}
+
+func getHotelPolicies(q Querier, hotelID int) ([]HotelPolicy, error) {
+	query := `
+		SELECT policy_type, name, description
+		FROM hotel_policies
+		WHERE hotel_id = $1
+		ORDER BY position`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.QueryContext(ctx, query, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []HotelPolicy{}
+	for rows.Next() {
+		var policy HotelPolicy
+
+		err := rows.Scan(&policy.PolicyType, &policy.Name, &policy.Description)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// The replace functions delete a section and insert its new rows with a
+// single unnest so the number of statements does not grow with the payload.
+// Positions keep Cupid's ordering.
+
+func replaceHotelPhotos(q Querier, hotel *Hotel) error {
+	var urls, hdURLs, descriptions, classes []string
+	var mainPhotos []bool
+	var scores []float64
+
+	for _, photo := range hotel.Photos {
+		urls = append(urls, photo.URL)
+		hdURLs = append(hdURLs, photo.HDURL)
+		descriptions = append(descriptions, photo.Description)
+		classes = append(classes, photo.Class)
+		mainPhotos = append(mainPhotos, photo.MainPhoto)
+		scores = append(scores, photo.Score)
+	}
+
+	query := `
+		INSERT INTO hotel_photos (hotel_id, position, url, hd_url, description, class, main_photo, score)
+		SELECT $1, t.position, t.url, t.hd_url, t.description, t.class, t.main_photo, t.score
+		FROM unnest($2::text[], $3::text[], $4::text[], $5::text[], $6::boolean[], $7::double precision[])
+			WITH ORDINALITY AS t(url, hd_url, description, class, main_photo, score, position)`
+
+	return replaceSection(q, "hotel_photos", hotel.HotelID, len(hotel.Photos), query,
+		hotel.HotelID, pq.Array(urls), pq.Array(hdURLs), pq.Array(descriptions), pq.Array(classes), pq.Array(mainPhotos), pq.Array(scores))
+}
+
+func replaceHotelFacilities(q Querier, hotel *Hotel) error {
+	var ids []int
+	var names []string
+
+	for _, facility := range hotel.Facilities {
+		ids = append(ids, facility.FacilityID)
+		names = append(names, facility.Name)
+	}
+
+	query := `
+		INSERT INTO hotel_facilities (hotel_id, position, facility_id, name)
+		SELECT $1, t.position, t.facility_id, t.name
+		FROM unnest($2::integer[], $3::text[])
+			WITH ORDINALITY AS t(facility_id, name, position)`
+
+	return replaceSection(q, "hotel_facilities", hotel.HotelID, len(hotel.Facilities), query,
+		hotel.HotelID, pq.Array(ids), pq.Array(names))
+}
+
+func replaceRooms(q Querier, hotel *Hotel) error {
+	var ids []int64
+	var names, descriptions, units []string
+	var sizes []float64
+	var adults, children, occupancy []int
+
+	for _, room := range hotel.Rooms {
+		ids = append(ids, room.ID)
+		names = append(names, room.RoomName)
+		descriptions = append(descriptions, room.Description)
+		sizes = append(sizes, room.RoomSizeSquare)
+		units = append(units, room.RoomSizeUnit)
+		adults = append(adults, room.MaxAdults)
+		children = append(children, room.MaxChildren)
+		occupancy = append(occupancy, room.MaxOccupancy)
+	}
+
+	query := `
+		INSERT INTO rooms (hotel_id, position, room_id, room_name, description, room_size_square, room_size_unit, max_adults, max_children, max_occupancy)
+		SELECT $1, t.position, t.room_id, t.room_name, t.description, t.room_size_square, t.room_size_unit, t.max_adults, t.max_children, t.max_occupancy
+		FROM unnest($2::bigint[], $3::text[], $4::text[], $5::double precision[], $6::text[], $7::integer[], $8::integer[], $9::integer[])
+			WITH ORDINALITY AS t(room_id, room_name, description, room_size_square, room_size_unit, max_adults, max_children, max_occupancy, position)`
+
+	return replaceSection(q, "rooms", hotel.HotelID, len(hotel.Rooms), query,
+		hotel.HotelID, pq.Array(ids), pq.Array(names), pq.Array(descriptions), pq.Array(sizes), pq.Array(units), pq.Array(adults), pq.Array(children), pq.Array(occupancy))
+}
+
+func replaceHotelPolicies(q Querier, hotel *Hotel) error {
+	var types, names, descriptions []string
+
+	for _, policy := range hotel.Policies {
+		types = append(types, policy.PolicyType)
+		names = append(names, policy.Name)
+		descriptions = append(descriptions, policy.Description)
+	}
+
+	query := `
+		INSERT INTO hotel_policies (hotel_id, position, policy_type, name, description)
+		SELECT $1, t.position, t.policy_type, t.name, t.description
+		FROM unnest($2::text[], $3::text[], $4::text[])
+			WITH ORDINALITY AS t(policy_type, name, description, position)`
+
+	return replaceSection(q, "hotel_policies", hotel.HotelID, len(hotel.Policies), query,
+		hotel.HotelID, pq.Array(types), pq.Array(names), pq.Array(descriptions))
+}
+
+// replaceSection deletes the rows of hotelID from table and, when the new
+// section has any items, runs insert with args.
+func replaceSection(q Querier, table string, hotelID int, items int, insert string, args ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := q.ExecContext(ctx, `DELETE FROM `+table+` WHERE hotel_id = $1`, hotelID)
+	if err != nil {
+		return err
+	}
+
+	if items == 0 {
+		return nil
+	}
+
+	_, err = q.ExecContext(ctx, insert, args...)
+	return err
+}
@@ -0,0 +1,116 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHotelDetailsModel_Load(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := HotelDetailsModel{DB: db}
+
+	mock.ExpectQuery(`SELECT (.+) FROM rooms WHERE hotel_id = \$1 ORDER BY position`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"room_id", "room_name", "description", "room_size_square", "room_size_unit", "max_adults", "max_children", "max_occupancy",
+		}).AddRow(int64(9001), "Double Room", "Sea view", 22.5, "m2", 2, 1, 3))
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_facilities WHERE hotel_id = \$1 ORDER BY position`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows([]string{"facility_id", "name"}))
+
+	hotel := &Hotel{HotelID: 123}
+
+	err = model.Load(hotel, SectionRooms, SectionFacilities)
+	if err != nil {
+		t.Fatalf("error was not expected while loading details: %s", err)
+	}
+
+	if len(hotel.Rooms) != 1 || hotel.Rooms[0].ID != 9001 || hotel.Rooms[0].MaxOccupancy != 3 {
+		t.Errorf("unexpected rooms: %+v", hotel.Rooms)
+	}
+	if hotel.Facilities == nil || len(hotel.Facilities) != 0 {
+		t.Errorf("expected a loaded empty section to be an empty slice, got %#v", hotel.Facilities)
+	}
+	if hotel.Photos != nil || hotel.Policies != nil {
+		t.Error("expected sections that were not asked for to stay nil")
+	}
+
+	err = model.Load(hotel, "amenities")
+	if err == nil {
+		t.Error("expected an error for an unknown section")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHotelDetailsModel_ReplaceTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := HotelDetailsModel{DB: db}
+
+	// Photos are not part of the payload, facilities are unchanged and the
+	// policies were removed upstream.
+	hotel := &Hotel{
+		HotelID:    123,
+		Facilities: []HotelFacility{{FacilityID: 47, Name: "WiFi"}},
+		Rooms:      []Room{{ID: 1, RoomName: "Suite", MaxAdults: 2}},
+		Policies:   []HotelPolicy{},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_facilities WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows([]string{"facility_id", "name"}).AddRow(47, "WiFi"))
+	mock.ExpectQuery(`SELECT (.+) FROM rooms WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"room_id", "room_name", "description", "room_size_square", "room_size_unit", "max_adults", "max_children", "max_occupancy",
+		}))
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_policies WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows([]string{"policy_type", "name", "description"}).AddRow("pets", "Pets", "Not allowed"))
+	mock.ExpectExec(`DELETE FROM rooms WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO rooms \(hotel_id, position, (.+)\) SELECT (.+) FROM unnest\((.+)\) WITH ORDINALITY`).
+		WithArgs(123, "{1}", `{"Suite"}`, `{""}`, sqlmock.AnyArg(), `{""}`, "{2}", "{0}", "{0}").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM hotel_policies WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := model.ReplaceTx(tx, hotel)
+	if err != nil {
+		t.Fatalf("error was not expected while replacing details: %s", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changed) != 2 || changed[0] != SectionRooms || changed[1] != SectionPolicies {
+		t.Errorf("expected rooms and policies to change, got %v", changed)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
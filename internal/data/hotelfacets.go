@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HotelFacets are the fields GET /v1/hotels can return per-value counts for,
+// in the order they are reported.
+var HotelFacets = []string{"country", "city", "stars", "pets_allowed"}
+
+// maxFacetValues caps how many values are returned per facet, most frequent
+// first, so a broad search does not return every city in the table.
+const maxFacetValues = 20
+
+// FacetCount is the number of matching hotels sharing one value of a facet.
+// Value is a string, an int or a bool depending on the facet.
+type FacetCount struct {
+	Value any `json:"value"`
+	Count int `json:"count"`
+}
+
+// Facets counts the hotels matching q by each of the given fields, which must
+// be in HotelFacets. All facets are computed in a single query with GROUPING
+// SETS; hotels without a value for a field are not counted for it.
+func (h HotelModel) Facets(q HotelQuery, fields []string) (map[string][]FacetCount, error) {
+	if len(fields) == 0 {
+		return map[string][]FacetCount{}, nil
+	}
+
+	sets := make([]string, len(fields))
+	names := make([]string, len(fields))
+	values := make([]string, len(fields))
+
+	for i, field := range fields {
+		if !slices.Contains(HotelFacets, field) {
+			return nil, fmt.Errorf("unknown hotel facet %q", field)
+		}
+
+		// field is one of HotelFacets, which are all column names.
+		sets[i] = "(" + field + ")"
+		names[i] = fmt.Sprintf("WHEN GROUPING(%s) = 0 THEN '%[1]s'", field)
+		values[i] = fmt.Sprintf("WHEN GROUPING(%s) = 0 THEN %[1]s::text", field)
+	}
+
+	// A NULL LIMIT selects every match; it is passed rather than dropped so the
+	// placeholders stay numbered the same as in GetAll.
+	from, args := q.from(nil, 0)
+
+	query := fmt.Sprintf(`
+		WITH matched AS (
+			SELECT country, city, stars, pets_allowed
+			%s
+			LIMIT $2 OFFSET $3
+		), counts AS (
+			SELECT CASE %s END AS facet, CASE %s END AS value, count(*) AS count
+			FROM matched
+			GROUP BY GROUPING SETS (%s)
+		)
+		SELECT facet, value, count
+		FROM (
+			SELECT facet, value, count, row_number() OVER (PARTITION BY facet ORDER BY count DESC, value ASC) AS rank
+			FROM counts
+			WHERE value IS NOT NULL AND value <> ''
+		) ranked
+		WHERE rank <= %d
+		ORDER BY facet, rank`,
+		from, strings.Join(names, " "), strings.Join(values, " "), strings.Join(sets, ", "), maxFacetValues)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := h.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := make(map[string][]FacetCount, len(fields))
+	for _, field := range fields {
+		facets[field] = []FacetCount{}
+	}
+
+	for rows.Next() {
+		var facet, value string
+		var count int
+
+		if err := rows.Scan(&facet, &value, &count); err != nil {
+			return nil, err
+		}
+
+		fc := FacetCount{Value: value, Count: count}
+
+		switch facet {
+		case "stars":
+			if fc.Value, err = strconv.Atoi(value); err != nil {
+				return nil, err
+			}
+		case "pets_allowed":
+			if fc.Value, err = strconv.ParseBool(value); err != nil {
+				return nil, err
+			}
+		}
+
+		facets[facet] = append(facets[facet], fc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHotelModel_Facets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	mock.ExpectQuery(`WITH matched AS \( SELECT country, city, stars, pets_allowed FROM hotels (.+) AND stars >= \$11 LIMIT \$2 OFFSET \$3 \), counts AS (.+) GROUP BY GROUPING SETS \(\(city\), \(stars\), \(pets_allowed\)\)`).
+		WithArgs("paris", nil, 0, nil, nil, nil, nil, nil, nil, nil, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"facet", "value", "count"}).
+			AddRow("city", "Paris", 42).
+			AddRow("city", "Versailles", 2).
+			AddRow("pets_allowed", "false", 30).
+			AddRow("pets_allowed", "true", 14))
+
+	minStars := 3
+	facets, err := hotelModel.Facets(HotelQuery{Search: "paris", MinStars: &minStars}, []string{"city", "stars", "pets_allowed"})
+	if err != nil {
+		t.Fatalf("error was not expected while counting facets: %s", err)
+	}
+
+	expected := map[string][]FacetCount{
+		"city":         {{Value: "Paris", Count: 42}, {Value: "Versailles", Count: 2}},
+		"stars":        {},
+		"pets_allowed": {{Value: false, Count: 30}, {Value: true, Count: 14}},
+	}
+	if !reflect.DeepEqual(facets, expected) {
+		t.Errorf("expected %v, got %v", expected, facets)
+	}
+
+	if _, err := hotelModel.Facets(HotelQuery{}, []string{"hotel_name"}); err == nil {
+		t.Error("expected an error for an unknown facet")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -5,7 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/JLL32/nuitee/internal/validator"
+	"github.com/lib/pq"
 )
 
 type Address struct {
@@ -23,6 +28,8 @@ type Hotel struct {
 	Phone        string    `json:"phone"`
 	Email        string    `json:"email"`
 	Address      Address   `json:"address"`
+	Latitude     *float64  `json:"latitude"`
+	Longitude    *float64  `json:"longitude"`
 	Stars        int       `json:"stars"`
 	Rating       float64   `json:"rating"`
 	ReviewCount  int       `json:"review_count"`
@@ -31,6 +38,78 @@ type Hotel struct {
 	Description  string    `json:"description"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// DistanceKm is set by GetAll when hotels are searched near a point and
+	// the hotel has coordinates.
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+
+	// Highlights holds snippets of the name and description with the search
+	// terms marked, set by GetAll for the fields that matched a search.
+	Highlights map[string]string `json:"highlights,omitempty"`
+
+	// Similarity is set by GetAll for fuzzy searches: the trigram word
+	// similarity between the search and the name and city, from 0 to 1.
+	Similarity *float64 `json:"similarity,omitempty"`
+
+	// Detail sections, nil unless loaded with HotelDetailsModel.Load or
+	// decoded from a Cupid payload that contains them.
+	Photos     []HotelPhoto    `json:"photos,omitzero"`
+	Facilities []HotelFacility `json:"facilities,omitzero"`
+	Rooms      []Room          `json:"rooms,omitzero"`
+	Policies   []HotelPolicy   `json:"policies,omitzero"`
+}
+
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// Diff lists the content fields that differ between h and other, with h as
+// the old and other as the new value. Timestamps are ignored and ratings are
+// compared at the precision they are stored with.
+func (h *Hotel) Diff(other *Hotel) []FieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"main_image_th", h.MainImageTh, other.MainImageTh},
+		{"hotel_name", h.HotelName, other.HotelName},
+		{"phone", h.Phone, other.Phone},
+		{"email", h.Email, other.Email},
+		{"address", h.Address.Address, other.Address.Address},
+		{"city", h.Address.City, other.Address.City},
+		{"state", h.Address.State, other.Address.State},
+		{"country", h.Address.Country, other.Address.Country},
+		{"postal_code", h.Address.PostalCode, other.Address.PostalCode},
+		{"latitude", formatCoordinate(h.Latitude), formatCoordinate(other.Latitude)},
+		{"longitude", formatCoordinate(h.Longitude), formatCoordinate(other.Longitude)},
+		{"stars", strconv.Itoa(h.Stars), strconv.Itoa(other.Stars)},
+		{"rating", strconv.FormatFloat(h.Rating, 'f', 2, 64), strconv.FormatFloat(other.Rating, 'f', 2, 64)},
+		{"review_count", strconv.Itoa(h.ReviewCount), strconv.Itoa(other.ReviewCount)},
+		{"child_allowed", strconv.FormatBool(h.ChildAllowed), strconv.FormatBool(other.ChildAllowed)},
+		{"pets_allowed", strconv.FormatBool(h.PetsAllowed), strconv.FormatBool(other.PetsAllowed)},
+		{"description", h.Description, other.Description},
+	}
+
+	var changes []FieldChange
+	for _, f := range fields {
+		if f.old != f.new {
+			changes = append(changes, FieldChange{Field: f.name, OldValue: f.old, NewValue: f.new})
+		}
+	}
+
+	return changes
+}
+
+// formatCoordinate renders a coordinate for the change log at roughly 10cm
+// precision, or as an empty string when it is unknown.
+func formatCoordinate(c *float64) string {
+	if c == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*c, 'f', 6, 64)
 }
 
 type HotelModel struct {
@@ -40,9 +119,9 @@ type HotelModel struct {
 func (h HotelModel) Insert(hotel *Hotel) error {
 	query :=
 		`INSERT INTO hotels (
-			hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description
+			hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING created_at, updated_at`
 
 	args := []any{
@@ -62,6 +141,8 @@ func (h HotelModel) Insert(hotel *Hotel) error {
 		hotel.ChildAllowed,
 		hotel.PetsAllowed,
 		hotel.Description,
+		hotel.Latitude,
+		hotel.Longitude,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -70,12 +151,24 @@ func (h HotelModel) Insert(hotel *Hotel) error {
 	return h.DB.QueryRowContext(ctx, query, args...).Scan(&hotel.CreatedAt, &hotel.UpdatedAt)
 }
 
-func (h HotelModel) Upsert(hotel *Hotel) error {
+// Upsert inserts hotel or updates the stored row with the same hotel_id. An
+// existing row whose content is identical is left untouched, updated_at
+// included. It reports whether a row was inserted or updated.
+func (h HotelModel) Upsert(hotel *Hotel) (bool, error) {
+	return upsertHotel(h.DB, hotel)
+}
+
+// UpsertTx is Upsert run as part of tx.
+func (h HotelModel) UpsertTx(tx *sql.Tx, hotel *Hotel) (bool, error) {
+	return upsertHotel(tx, hotel)
+}
+
+func upsertHotel(q Querier, hotel *Hotel) (bool, error) {
 	query :=
 		`INSERT INTO hotels (
-			hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description
+			hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		ON CONFLICT (hotel_id) DO UPDATE SET
 			main_image_th = EXCLUDED.main_image_th,
 			hotel_name = EXCLUDED.hotel_name,
@@ -92,7 +185,18 @@ func (h HotelModel) Upsert(hotel *Hotel) error {
 			child_allowed = EXCLUDED.child_allowed,
 			pets_allowed = EXCLUDED.pets_allowed,
 			description = EXCLUDED.description,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
 			updated_at = CURRENT_TIMESTAMP
+		WHERE (
+			hotels.main_image_th, hotels.hotel_name, hotels.phone, hotels.email, hotels.address, hotels.city, hotels.state, hotels.country,
+			hotels.postal_code, hotels.stars, hotels.rating, hotels.review_count, hotels.child_allowed, hotels.pets_allowed, hotels.description,
+			hotels.latitude, hotels.longitude
+		) IS DISTINCT FROM (
+			EXCLUDED.main_image_th, EXCLUDED.hotel_name, EXCLUDED.phone, EXCLUDED.email, EXCLUDED.address, EXCLUDED.city, EXCLUDED.state, EXCLUDED.country,
+			EXCLUDED.postal_code, EXCLUDED.stars, EXCLUDED.rating, EXCLUDED.review_count, EXCLUDED.child_allowed, EXCLUDED.pets_allowed, EXCLUDED.description,
+			EXCLUDED.latitude, EXCLUDED.longitude
+		)
 		RETURNING created_at, updated_at`
 
 	args := []any{
@@ -112,30 +216,61 @@ func (h HotelModel) Upsert(hotel *Hotel) error {
 		hotel.ChildAllowed,
 		hotel.PetsAllowed,
 		hotel.Description,
+		hotel.Latitude,
+		hotel.Longitude,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return h.DB.QueryRowContext(ctx, query, args...).Scan(&hotel.CreatedAt, &hotel.UpdatedAt)
+	// The WHERE clause suppresses no-op updates, in which case no row is
+	// returned.
+	err := q.QueryRowContext(ctx, query, args...).Scan(&hotel.CreatedAt, &hotel.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
 }
 
 func (h HotelModel) Get(id int64) (*Hotel, error) {
-	if id <= 0 {
-		return nil, ErrRecordNotFound
-	}
-
 	query :=
-		`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at
+		`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at
 		FROM hotels
 		WHERE hotel_id = $1`
 
+	return getHotel(h.DB, query, id)
+}
+
+// GetForUpdateTx is Get run as part of tx, locking the row until the
+// transaction ends so concurrent writers cannot interleave with a
+// read-compare-write.
+func (h HotelModel) GetForUpdateTx(tx *sql.Tx, id int64) (*Hotel, error) {
+	query :=
+		`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at
+		FROM hotels
+		WHERE hotel_id = $1
+		FOR UPDATE`
+
+	return getHotel(tx, query, id)
+}
+
+func getHotel(q Querier, query string, id int64) (*Hotel, error) {
+	if id <= 0 {
+		return nil, ErrRecordNotFound
+	}
+
 	var hotel Hotel
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := h.DB.QueryRowContext(ctx, query, id).Scan(
+	err := q.QueryRowContext(ctx, query, id).Scan(
 		&hotel.HotelID,
 		&hotel.MainImageTh,
 		&hotel.HotelName,
@@ -152,6 +287,8 @@ func (h HotelModel) Get(id int64) (*Hotel, error) {
 		&hotel.ChildAllowed,
 		&hotel.PetsAllowed,
 		&hotel.Description,
+		&hotel.Latitude,
+		&hotel.Longitude,
 		&hotel.CreatedAt,
 		&hotel.UpdatedAt,
 	)
@@ -168,18 +305,258 @@ func (h HotelModel) Get(id int64) (*Hotel, error) {
 	return &hotel, nil
 }
 
-func (h HotelModel) GetAll(search string, filters Filters) ([]*Hotel, Metadata, error) {
-	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at
+// HotelQuery holds the conditions GetAll selects hotels by. Fields left at
+// their zero value do not filter.
+type HotelQuery struct {
+	Search     string
+	SearchMode string
+	// Fuzzy also matches hotels whose name and city resemble a plain search,
+	// so misspelled searches still find them.
+	Fuzzy bool
+
+	Near *Near
+	BBox *BBox
+
+	// Country and City match case-insensitively.
+	Country string
+	City    string
+
+	// Stars keeps hotels with any of the listed star ratings; MinStars and
+	// MaxStars bound them instead. Both may be combined.
+	Stars    []int
+	MinStars *int
+	MaxStars *int
+
+	MinRating      *float64
+	MaxRating      *float64
+	MinReviewCount *int
+	PetsAllowed    *bool
+	ChildAllowed   *bool
+}
+
+func ValidateHotelQuery(v *validator.Validator, q HotelQuery) {
+	ValidateSearch(v, q.Search, q.SearchMode)
+	if q.Fuzzy {
+		v.Check(q.SearchMode == "" || q.SearchMode == SearchPlain, "fuzzy", "can only be used with q_mode plain")
+	}
+
+	if q.Near != nil {
+		ValidateNear(v, *q.Near)
+	}
+	if q.BBox != nil {
+		ValidateBBox(v, *q.BBox)
+	}
+
+	v.Check(q.Country == "" || validator.Matches(q.Country, validator.CountryCodeRX), "country", "must be a two-letter country code")
+	v.Check(len(q.City) <= 100, "city", "must not be more than 100 bytes long")
+
+	for _, stars := range q.Stars {
+		v.Check(stars >= 0 && stars <= 5, "stars", "must be between 0 and 5")
+	}
+	v.Check(validator.Unique(q.Stars), "stars", "must not contain duplicate values")
+	if q.MinStars != nil {
+		v.Check(*q.MinStars >= 0 && *q.MinStars <= 5, "min_stars", "must be between 0 and 5")
+	}
+	if q.MaxStars != nil {
+		v.Check(*q.MaxStars >= 0 && *q.MaxStars <= 5, "max_stars", "must be between 0 and 5")
+	}
+	if q.MinStars != nil && q.MaxStars != nil {
+		v.Check(*q.MinStars <= *q.MaxStars, "min_stars", "must not be greater than max_stars")
+	}
+
+	if q.MinRating != nil {
+		v.Check(*q.MinRating >= 0 && *q.MinRating <= 10, "min_rating", "must be between 0 and 10")
+	}
+	if q.MaxRating != nil {
+		v.Check(*q.MaxRating >= 0 && *q.MaxRating <= 10, "max_rating", "must be between 0 and 10")
+	}
+	if q.MinRating != nil && q.MaxRating != nil {
+		v.Check(*q.MinRating <= *q.MaxRating, "min_rating", "must not be greater than max_rating")
+	}
+
+	if q.MinReviewCount != nil {
+		v.Check(*q.MinReviewCount >= 0, "min_review_count", "must not be negative")
+	}
+}
+
+// attributeFilters appends a condition for every attribute filter that is set,
+// each bound to a new placeholder after the ones already in args, so user input
+// never ends up in the SQL text.
+func (q HotelQuery) attributeFilters(args []any) (string, []any) {
+	var conditions strings.Builder
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions.WriteString("\n\t\tAND ")
+		conditions.WriteString(strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if q.Country != "" {
+		add("lower(country) = lower(?)", q.Country)
+	}
+	if q.City != "" {
+		add("lower(city) = lower(?)", q.City)
+	}
+	if len(q.Stars) > 0 {
+		add("stars = ANY(?::integer[])", pq.Array(q.Stars))
+	}
+	if q.MinStars != nil {
+		add("stars >= ?", *q.MinStars)
+	}
+	if q.MaxStars != nil {
+		add("stars <= ?", *q.MaxStars)
+	}
+	if q.MinRating != nil {
+		add("rating >= ?", *q.MinRating)
+	}
+	if q.MaxRating != nil {
+		add("rating <= ?", *q.MaxRating)
+	}
+	if q.MinReviewCount != nil {
+		add("review_count >= ?", *q.MinReviewCount)
+	}
+	if q.PetsAllowed != nil {
+		add("pets_allowed = ?", *q.PetsAllowed)
+	}
+	if q.ChildAllowed != nil {
+		add("child_allowed = ?", *q.ChildAllowed)
+	}
+
+	return conditions.String(), args
+}
+
+// Near restricts a hotel search to hotels around a point and adds each
+// hotel's distance from it to the results. A zero RadiusKm only computes
+// distances without filtering.
+type Near struct {
+	Lat      float64
+	Lng      float64
+	RadiusKm float64
+}
+
+func ValidateNear(v *validator.Validator, n Near) {
+	v.Check(n.Lat >= -90 && n.Lat <= 90, "lat", "must be between -90 and 90")
+	v.Check(n.Lng >= -180 && n.Lng <= 180, "lng", "must be between -180 and 180")
+	v.Check(n.RadiusKm >= 0, "radius_km", "must not be negative")
+	v.Check(n.RadiusKm <= 20_000, "radius_km", "must be a maximum of 20000")
+}
+
+// BBox restricts a hotel search to a map viewport, in the GeoJSON order
+// minLng, minLat, maxLng, maxLat. A MinLng greater than MaxLng describes a
+// viewport that crosses the antimeridian.
+type BBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+func ValidateBBox(v *validator.Validator, b BBox) {
+	v.Check(b.MinLng >= -180 && b.MinLng <= 180 && b.MaxLng >= -180 && b.MaxLng <= 180, "bbox", "longitudes must be between -180 and 180")
+	v.Check(b.MinLat >= -90 && b.MinLat <= 90 && b.MaxLat >= -90 && b.MaxLat <= 90, "bbox", "latitudes must be between -90 and 90")
+	v.Check(b.MinLat <= b.MaxLat, "bbox", "minimum latitude must not be greater than the maximum")
+}
+
+// from builds the FROM and WHERE clauses selecting the hotels that match q,
+// exposing each hotel's distance from q.Near as a distance column. $2 and $3
+// are reserved for the caller's LIMIT and OFFSET.
+func (q HotelQuery) from(limit, offset any) (string, []any) {
+	args := []any{searchArg(q.Search, q.SearchMode), limit, offset, nil, nil, nil, nil, nil, nil, nil}
+	if q.Near != nil {
+		args[3], args[4] = q.Near.Lat, q.Near.Lng
+		if q.Near.RadiusKm > 0 {
+			args[5] = q.Near.RadiusKm
+		}
+	}
+	if q.BBox != nil {
+		args[6], args[7], args[8], args[9] = q.BBox.MinLng, q.BBox.MinLat, q.BBox.MaxLng, q.BBox.MaxLat
+	}
+
+	attributes, args := q.attributeFilters(args)
+
+	match := "fts @@ " + hotelSearchQuery(q.SearchMode)
+	if q.Fuzzy {
+		match = "(" + match + " OR " + hotelTrigramMatch + ")"
+	}
+
+	from := fmt.Sprintf(`
 		FROM hotels
-		WHERE fts @@ plainto_tsquery('simple', $1) OR $1 = ''
-		ORDER BY %s %s, hotel_id ASC
-		LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+		CROSS JOIN LATERAL (
+			SELECT CASE WHEN $4::double precision IS NULL THEN NULL ELSE %s END AS distance
+		) d
+		WHERE (%[4]s OR $1 = '')
+		AND ($6::double precision IS NULL OR (
+			latitude BETWEEN $4 - $6 / %[2]v AND $4 + $6 / %[2]v AND distance <= $6
+		))
+		AND ($7::double precision IS NULL OR (
+			latitude BETWEEN $8::double precision AND $10::double precision
+			AND CASE WHEN $7 <= $9::double precision
+				THEN longitude BETWEEN $7 AND $9
+				ELSE longitude >= $7 OR longitude <= $9
+			END
+		))%[3]s`, haversineKm, kmPerDegreeLatitude, attributes, match)
+
+	return from, args
+}
+
+// haversineKm is the great-circle distance in kilometres between a hotel and
+// the point ($4, $5). It is plain SQL so it runs on stock Postgres without
+// the cube or earthdistance extensions, and is NULL for hotels without
+// coordinates.
+const haversineKm = `2 * 6371 * asin(least(1, sqrt(
+	power(sin(radians(latitude - $4::double precision) / 2), 2) +
+	cos(radians($4::double precision)) * cos(radians(latitude)) *
+	power(sin(radians(longitude - $5::double precision) / 2), 2)
+)))`
+
+// kmPerDegreeLatitude is used to narrow radius searches down to a latitude
+// band, which idx_hotels_latitude can serve, before computing distances.
+const kmPerDegreeLatitude = 111.045
+
+// GetAll returns a page of hotels matching q. When q.Near is set the
+// distance of each hotel from the point is returned in DistanceKm, hotels
+// further than its RadiusKm are left out, and the results can be sorted by
+// distance. Hotels without coordinates never match q.BBox.
+func (h HotelModel) GetAll(q HotelQuery, filters Filters) ([]*Hotel, Metadata, error) {
+	fuzzy := q.Fuzzy && q.Search != ""
+
+	orderBy := fmt.Sprintf("%s %s", filters.sortColumn(), filters.sortDirection())
+	switch filters.sortColumn() {
+	case "distance":
+		orderBy += " NULLS LAST"
+	case "relevance":
+		orderBy = relevanceOrder(hotelSearchQuery(q.SearchMode))
+		if fuzzy {
+			orderBy = hotelSimilarity + " DESC, " + orderBy
+		}
+	}
+
+	var similarity string
+	if fuzzy {
+		similarity = ", " + hotelSimilarity
+	}
+
+	// Snippets are only worth computing when there is a search to highlight.
+	var snippets string
+	if q.Search != "" {
+		snippets = fmt.Sprintf(", %s, %s",
+			headline("'simple'", "hotel_name", hotelSearchQuery(q.SearchMode), headlineWhole),
+			headline("'english'", "description", hotelSearchQuery(q.SearchMode), headlineFragments),
+		)
+	}
+
+	from, args := q.from(filters.limit(), filters.offset())
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance%s%s
+		%s
+		ORDER BY %s, hotel_id ASC
+		LIMIT $2 OFFSET $3`, similarity, snippets, from, orderBy)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := h.DB.QueryContext(ctx, query, search, filters.limit(), filters.offset())
+	rows, err := h.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -190,8 +567,10 @@ func (h HotelModel) GetAll(search string, filters Filters) ([]*Hotel, Metadata,
 
 	for rows.Next() {
 		var hotel Hotel
+		var distance, similarityScore sql.NullFloat64
+		var nameSnippet, descriptionSnippet sql.NullString
 
-		err := rows.Scan(
+		dest := []any{
 			&totalRecords,
 			&hotel.HotelID,
 			&hotel.MainImageTh,
@@ -209,14 +588,35 @@ func (h HotelModel) GetAll(search string, filters Filters) ([]*Hotel, Metadata,
 			&hotel.ChildAllowed,
 			&hotel.PetsAllowed,
 			&hotel.Description,
+			&hotel.Latitude,
+			&hotel.Longitude,
 			&hotel.CreatedAt,
 			&hotel.UpdatedAt,
-		)
+			&distance,
+		}
+		if fuzzy {
+			dest = append(dest, &similarityScore)
+		}
+		if q.Search != "" {
+			dest = append(dest, &nameSnippet, &descriptionSnippet)
+		}
 
+		err := rows.Scan(dest...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
 
+		if distance.Valid {
+			hotel.DistanceKm = &distance.Float64
+		}
+		if similarityScore.Valid {
+			hotel.Similarity = &similarityScore.Float64
+		}
+		hotel.Highlights = highlights(map[string]sql.NullString{
+			"hotel_name":  nameSnippet,
+			"description": descriptionSnippet,
+		})
+
 		hotels = append(hotels, &hotel)
 	}
 
@@ -3,10 +3,13 @@ package data
 import (
 	"database/sql"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/JLL32/nuitee/internal/validator"
 )
 
 func TestHotelModel_Insert(t *testing.T) {
@@ -60,6 +63,8 @@ func TestHotelModel_Insert(t *testing.T) {
 			hotel.ChildAllowed,
 			hotel.PetsAllowed,
 			hotel.Description,
+			hotel.Latitude,
+			hotel.Longitude,
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).
 			AddRow(createdAt, updatedAt))
@@ -101,7 +106,8 @@ func TestHotelModel_Insert_Error(t *testing.T) {
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
 			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
 			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
-			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(sql.ErrConnDone)
 
 	err = hotelModel.Insert(hotel)
@@ -151,19 +157,19 @@ func TestHotelModel_Get(t *testing.T) {
 		UpdatedAt:    time.Now(),
 	}
 
-	mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+	mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 		WithArgs(int64(123)).
 		WillReturnRows(sqlmock.NewRows([]string{
 			"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 			"city", "state", "country", "postal_code", "stars", "rating",
-			"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+			"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
 		}).AddRow(
 			expectedHotel.HotelID, expectedHotel.MainImageTh, expectedHotel.HotelName,
 			expectedHotel.Phone, expectedHotel.Email, expectedHotel.Address.Address,
 			expectedHotel.Address.City, expectedHotel.Address.State, expectedHotel.Address.Country,
 			expectedHotel.Address.PostalCode, expectedHotel.Stars, expectedHotel.Rating,
 			expectedHotel.ReviewCount, expectedHotel.ChildAllowed, expectedHotel.PetsAllowed,
-			expectedHotel.Description, expectedHotel.CreatedAt, expectedHotel.UpdatedAt,
+			expectedHotel.Description, nil, nil, expectedHotel.CreatedAt, expectedHotel.UpdatedAt,
 		))
 
 	hotel, err := hotelModel.Get(123)
@@ -190,7 +196,7 @@ func TestHotelModel_Get_NotFound(t *testing.T) {
 
 	hotelModel := HotelModel{DB: db}
 
-	mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+	mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 		WithArgs(int64(999)).
 		WillReturnError(sql.ErrNoRows)
 
@@ -305,7 +311,8 @@ func TestHotelModel_GetAll(t *testing.T) {
 	rows := sqlmock.NewRows([]string{
 		"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 		"city", "state", "country", "postal_code", "stars", "rating",
-		"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+		"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+		"hotel_name_snippet", "description_snippet",
 	})
 
 	for _, hotel := range expectedHotels {
@@ -315,15 +322,17 @@ func TestHotelModel_GetAll(t *testing.T) {
 			hotel.Address.City, hotel.Address.State, hotel.Address.Country,
 			hotel.Address.PostalCode, hotel.Stars, hotel.Rating,
 			hotel.ReviewCount, hotel.ChildAllowed, hotel.PetsAllowed,
-			hotel.Description, hotel.CreatedAt, hotel.UpdatedAt,
+			hotel.Description, nil, nil, hotel.CreatedAt, hotel.UpdatedAt, nil,
+			strings.Replace(hotel.HotelName, "Test", "<mark>Test</mark>", 1),
+			strings.Replace(hotel.Description, "test", "<mark>test</mark>", 1),
 		)
 	}
 
-	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-		WithArgs("test search", 20, 0).
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance, ts_headline\('simple', coalesce\(hotel_name, ''\), (.+)\), ts_headline\('english', coalesce\(description, ''\), (.+)\) FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs("test search", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 		WillReturnRows(rows)
 
-	hotels, metadata, err := hotelModel.GetAll("test search", filters)
+	hotels, metadata, err := hotelModel.GetAll(HotelQuery{Search: "test search"}, filters)
 
 	if err != nil {
 		t.Errorf("error was not expected while getting all hotels: %s", err)
@@ -345,6 +354,10 @@ func TestHotelModel_GetAll(t *testing.T) {
 		t.Errorf("expected PageSize to be 20, got %d", metadata.PageSize)
 	}
 
+	if len(hotels) > 0 && hotels[0].Highlights["description"] != "A wonderful <mark>test</mark> hotel 1" {
+		t.Errorf("expected a highlighted description, got %v", hotels[0].Highlights)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
@@ -369,14 +382,14 @@ func TestHotelModel_GetAll_EmptyResult(t *testing.T) {
 	rows := sqlmock.NewRows([]string{
 		"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 		"city", "state", "country", "postal_code", "stars", "rating",
-		"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+		"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
 	})
 
-	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-		WithArgs("", 20, 0).
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 		WillReturnRows(rows)
 
-	hotels, metadata, err := hotelModel.GetAll("", filters)
+	hotels, metadata, err := hotelModel.GetAll(HotelQuery{}, filters)
 
 	if err != nil {
 		t.Errorf("error was not expected while getting all hotels: %s", err)
@@ -411,11 +424,11 @@ func TestHotelModel_GetAll_DatabaseError(t *testing.T) {
 		SortSafelist: []string{"hotel_id", "name"},
 	}
 
-	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE fts @@ plainto_tsquery\('simple', \$1\) OR \$1 = '' ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
-		WithArgs("", 20, 0).
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at, distance FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE \(fts @@ \(plainto_tsquery\('simple', \$1\) \|\| plainto_tsquery\('english', \$1\)\) OR \$1 = ''\) AND (.+) ORDER BY hotel_id ASC, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil).
 		WillReturnError(sql.ErrConnDone)
 
-	hotels, metadata, err := hotelModel.GetAll("", filters)
+	hotels, metadata, err := hotelModel.GetAll(HotelQuery{}, filters)
 
 	if err == nil {
 		t.Error("expected error, but got none")
@@ -472,7 +485,7 @@ func TestHotelModel_Upsert(t *testing.T) {
 	createdAt := time.Now()
 	updatedAt := time.Now()
 
-	mock.ExpectQuery(`INSERT INTO hotels \(\s*hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description\s*\)\s*VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13, \$14, \$15, \$16\)\s*ON CONFLICT \(hotel_id\) DO UPDATE SET.*RETURNING created_at, updated_at`).
+	mock.ExpectQuery(`INSERT INTO hotels \(\s*hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude\s*\)\s*VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13, \$14, \$15, \$16, \$17, \$18\)\s*ON CONFLICT \(hotel_id\) DO UPDATE SET.*WHERE \(.*\) IS DISTINCT FROM \(.*\)\s*RETURNING created_at, updated_at`).
 		WithArgs(
 			hotel.HotelID,
 			hotel.MainImageTh,
@@ -490,16 +503,22 @@ func TestHotelModel_Upsert(t *testing.T) {
 			hotel.ChildAllowed,
 			hotel.PetsAllowed,
 			hotel.Description,
+			hotel.Latitude,
+			hotel.Longitude,
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).
 			AddRow(createdAt, updatedAt))
 
-	err = hotelModel.Upsert(hotel)
+	written, err := hotelModel.Upsert(hotel)
 
 	if err != nil {
 		t.Errorf("error was not expected while upserting hotel: %s", err)
 	}
 
+	if !written {
+		t.Error("expected the hotel to be reported as written")
+	}
+
 	if hotel.CreatedAt != createdAt {
 		t.Errorf("expected CreatedAt to be %v, got %v", createdAt, hotel.CreatedAt)
 	}
@@ -527,14 +546,15 @@ func TestHotelModel_Upsert_Error(t *testing.T) {
 		HotelName: "Test Hotel",
 	}
 
-	mock.ExpectQuery(`INSERT INTO hotels \(\s*hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description\s*\)\s*VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13, \$14, \$15, \$16\)\s*ON CONFLICT \(hotel_id\) DO UPDATE SET.*RETURNING created_at, updated_at`).
+	mock.ExpectQuery(`INSERT INTO hotels \(\s*hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude\s*\)\s*VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13, \$14, \$15, \$16, \$17, \$18\)\s*ON CONFLICT \(hotel_id\) DO UPDATE SET.*RETURNING created_at, updated_at`).
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
 			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
 			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
-			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(sql.ErrConnDone)
 
-	err = hotelModel.Upsert(hotel)
+	_, err = hotelModel.Upsert(hotel)
 
 	if err == nil {
 		t.Error("expected error, but got none")
@@ -549,6 +569,118 @@ func TestHotelModel_Upsert_Error(t *testing.T) {
 	}
 }
 
+func TestHotelModel_Upsert_Unchanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	// With identical content the conditional update touches no row, so
+	// nothing is returned.
+	mock.ExpectQuery(`INSERT INTO hotels (.+) ON CONFLICT \(hotel_id\) DO UPDATE SET (.+) IS DISTINCT FROM`).
+		WillReturnError(sql.ErrNoRows)
+
+	written, err := hotelModel.Upsert(&Hotel{HotelID: 123, HotelName: "Test Hotel"})
+	if err != nil {
+		t.Errorf("error was not expected for an unchanged hotel: %s", err)
+	}
+
+	if written {
+		t.Error("expected an unchanged hotel not to be reported as written")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHotelModel_GetForUpdateTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+		WithArgs(int64(123)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+			"city", "state", "country", "postal_code", "stars", "rating",
+			"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
+		}).AddRow(123, "", "Test Hotel", "", "", "", "", "", "", "", 4, 4.2, 10, true, false, "", nil, nil, now, now))
+	mock.ExpectQuery(`SELECT (.+) FROM hotels WHERE hotel_id = \$1 FOR UPDATE`).
+		WithArgs(int64(456)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	hotel, err := hotelModel.GetForUpdateTx(tx, 123)
+	if err != nil {
+		t.Fatalf("error was not expected while getting hotel: %s", err)
+	}
+
+	if hotel.HotelName != "Test Hotel" || hotel.Stars != 4 {
+		t.Errorf("unexpected hotel: %+v", hotel)
+	}
+
+	_, err = hotelModel.GetForUpdateTx(tx, 456)
+	if err != ErrRecordNotFound {
+		t.Errorf("expected error to be %v, got %v", ErrRecordNotFound, err)
+	}
+}
+
+func TestHotel_Diff(t *testing.T) {
+	old := &Hotel{
+		HotelID:     1,
+		HotelName:   "Old Name",
+		Address:     Address{City: "Paris"},
+		Stars:       4,
+		Rating:      4.5,
+		PetsAllowed: false,
+		CreatedAt:   time.Now().Add(-time.Hour),
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		same := *old
+		same.Rating = 4.501
+		same.CreatedAt = time.Now()
+
+		if changes := old.Diff(&same); len(changes) != 0 {
+			t.Errorf("expected no changes, got %+v", changes)
+		}
+	})
+
+	t.Run("changed fields", func(t *testing.T) {
+		updated := *old
+		updated.HotelName = "New Name"
+		updated.Address.City = "Lyon"
+		updated.PetsAllowed = true
+
+		expected := []FieldChange{
+			{Field: "hotel_name", OldValue: "Old Name", NewValue: "New Name"},
+			{Field: "city", OldValue: "Paris", NewValue: "Lyon"},
+			{Field: "pets_allowed", OldValue: "false", NewValue: "true"},
+		}
+
+		if changes := old.Diff(&updated); !reflect.DeepEqual(changes, expected) {
+			t.Errorf("expected %+v, got %+v", expected, changes)
+		}
+	})
+}
+
 func BenchmarkHotelModel_Get(b *testing.B) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -559,16 +691,16 @@ func BenchmarkHotelModel_Get(b *testing.B) {
 	hotelModel := HotelModel{DB: db}
 
 	for i := 0; i < b.N; i++ {
-		mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
+		mock.ExpectQuery(`SELECT hotel_id, main_image_th, hotel_name, phone, email, address, city, state, country, postal_code, stars, rating, review_count, child_allowed, pets_allowed, description, latitude, longitude, created_at, updated_at FROM hotels WHERE hotel_id = \$1`).
 			WithArgs(int64(123)).
 			WillReturnRows(sqlmock.NewRows([]string{
 				"hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
 				"city", "state", "country", "postal_code", "stars", "rating",
-				"review_count", "child_allowed", "pets_allowed", "description", "created_at", "updated_at",
+				"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at",
 			}).AddRow(
 				123, "image.jpg", "Test Hotel", "123-456-7890", "test@hotel.com", "123 Main St",
 				"Test City", "Test State", "Test Country", "12345", 5, 4.5,
-				100, true, false, "A wonderful test hotel", time.Now(), time.Now(),
+				100, true, false, "A wonderful test hotel", nil, nil, time.Now(), time.Now(),
 			))
 	}
 
@@ -581,3 +713,260 @@ func BenchmarkHotelModel_Get(b *testing.B) {
 		}
 	}
 }
+
+func TestHotelModel_GetAll_Near(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "-distance",
+		SortSafelist: []string{"distance", "-distance"},
+	}
+
+	now := time.Now()
+	lat, lng := 43.695, 7.265
+
+	rows := sqlmock.NewRows([]string{
+		"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+		"city", "state", "country", "postal_code", "stars", "rating",
+		"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+	}).AddRow(1, 123, "", "Harbour View", "", "", "", "Nice", "", "fr", "", 4, 8.7, 3, true, false, "", lat, lng, now, now, 1.25)
+
+	mock.ExpectQuery(`FROM hotels CROSS JOIN LATERAL \((.+)\) d WHERE (.+) AND \(\$6::double precision IS NULL OR \((.+) distance <= \$6 \)\) AND (.+) ORDER BY distance DESC NULLS LAST, hotel_id ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs("", 20, 0, 43.7, 7.26, 5.0, nil, nil, nil, nil).
+		WillReturnRows(rows)
+
+	hotels, _, err := hotelModel.GetAll(HotelQuery{Near: &Near{Lat: 43.7, Lng: 7.26, RadiusKm: 5}}, filters)
+	if err != nil {
+		t.Fatalf("error was not expected while getting hotels near a point: %s", err)
+	}
+
+	if len(hotels) != 1 || hotels[0].DistanceKm == nil || *hotels[0].DistanceKm != 1.25 {
+		t.Errorf("expected the distance to be returned, got %+v", hotels)
+	}
+	if hotels[0].Latitude == nil || *hotels[0].Latitude != lat {
+		t.Errorf("expected the coordinates to be returned, got %+v", hotels[0])
+	}
+
+	// Without a radius, distances are computed but nothing is filtered out.
+	mock.ExpectQuery(`FROM hotels CROSS JOIN LATERAL`).
+		WithArgs("", 20, 0, 43.7, 7.26, nil, nil, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+	_, _, err = hotelModel.GetAll(HotelQuery{Near: &Near{Lat: 43.7, Lng: 7.26}}, filters)
+	if err != nil {
+		t.Fatalf("error was not expected while getting hotels near a point: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHotelModel_GetAll_BBox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "hotel_id",
+		SortSafelist: []string{"hotel_id"},
+	}
+
+	mock.ExpectQuery(`AND \(\$7::double precision IS NULL OR \( latitude BETWEEN \$8::double precision AND \$10::double precision AND CASE WHEN \$7 <= \$9::double precision (.+) END \)\) ORDER BY hotel_id ASC`).
+		WithArgs("", 20, 0, nil, nil, nil, 7.2, 43.6, 7.3, 43.8).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+	_, _, err = hotelModel.GetAll(HotelQuery{BBox: &BBox{MinLng: 7.2, MinLat: 43.6, MaxLng: 7.3, MaxLat: 43.8}}, filters)
+	if err != nil {
+		t.Fatalf("error was not expected while getting hotels in a bounding box: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHotelModel_GetAll_Attributes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "-rating",
+		SortSafelist: []string{"-rating"},
+	}
+
+	minStars, minRating, petsAllowed := 3, 8.5, true
+
+	mock.ExpectQuery(`END \)\) AND lower\(country\) = lower\(\$11\) AND stars = ANY\(\$12::integer\[\]\) AND stars >= \$13 AND rating >= \$14 AND pets_allowed = \$15 ORDER BY rating DESC`).
+		WithArgs("", 20, 0, nil, nil, nil, nil, nil, nil, nil, "FR", "{4,5}", 3, 8.5, true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}))
+
+	_, _, err = hotelModel.GetAll(HotelQuery{
+		Country:     "FR",
+		Stars:       []int{4, 5},
+		MinStars:    &minStars,
+		MinRating:   &minRating,
+		PetsAllowed: &petsAllowed,
+	}, filters)
+	if err != nil {
+		t.Fatalf("error was not expected while filtering hotels: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHotelModel_GetAll_Fuzzy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "relevance",
+		SortSafelist: []string{"relevance"},
+	}
+
+	now := time.Now()
+
+	mock.ExpectQuery(`distance, word_similarity\(\$1, \(hotel_name \|\| ' ' \|\| coalesce\(city, ''\)\)\), ts_headline(.+) WHERE \(\(fts @@ (.+) OR \$1 <% \(hotel_name \|\| ' ' \|\| coalesce\(city, ''\)\)\) OR \$1 = ''\) (.+) ORDER BY word_similarity\((.+)\) DESC, ts_rank_cd\((.+)\) DESC, hotel_id ASC`).
+		WithArgs("Hiltn Pris", 20, 0, nil, nil, nil, nil, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"count", "hotel_id", "main_image_th", "hotel_name", "phone", "email", "address",
+			"city", "state", "country", "postal_code", "stars", "rating",
+			"review_count", "child_allowed", "pets_allowed", "description", "latitude", "longitude", "created_at", "updated_at", "distance",
+			"similarity", "hotel_name_snippet", "description_snippet",
+		}).AddRow(1, 42, "", "Hilton Paris Opera", "", "", "", "Paris", "", "fr", "", 5, 8.9, 120, true, false, "", nil, nil, now, now, nil,
+			0.64, "Hilton Paris Opera", ""))
+
+	hotels, _, err := hotelModel.GetAll(HotelQuery{Search: "Hiltn Pris", Fuzzy: true}, filters)
+	if err != nil {
+		t.Fatalf("error was not expected while searching hotels: %s", err)
+	}
+
+	if len(hotels) != 1 || hotels[0].Similarity == nil || *hotels[0].Similarity != 0.64 {
+		t.Errorf("expected the similarity to be returned, got %+v", hotels)
+	}
+	if hotels[0].Highlights != nil {
+		t.Errorf("expected no highlights for a trigram-only match, got %v", hotels[0].Highlights)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestValidateHotelQuery(t *testing.T) {
+	three, four, negative := 3, 4, -1
+	low, high := 6.0, 11.0
+
+	tests := []struct {
+		name  string
+		query HotelQuery
+		field string
+	}{
+		{name: "valid", query: HotelQuery{Country: "fr", Stars: []int{3, 4}, MinStars: &three, MaxStars: &four, MinRating: &low}},
+		{name: "country code too long", query: HotelQuery{Country: "france"}, field: "country"},
+		{name: "country code too short", query: HotelQuery{Country: "f"}, field: "country"},
+		{name: "country code with digits", query: HotelQuery{Country: "f1"}, field: "country"},
+		{name: "country code not ascii", query: HotelQuery{Country: "é"}, field: "country"},
+		{name: "stars out of range", query: HotelQuery{Stars: []int{6}}, field: "stars"},
+		{name: "duplicate stars", query: HotelQuery{Stars: []int{4, 4}}, field: "stars"},
+		{name: "star bounds reversed", query: HotelQuery{MinStars: &four, MaxStars: &three}, field: "min_stars"},
+		{name: "rating out of range", query: HotelQuery{MaxRating: &high}, field: "max_rating"},
+		{name: "negative review count", query: HotelQuery{MinReviewCount: &negative}, field: "min_review_count"},
+		{name: "invalid bbox", query: HotelQuery{BBox: &BBox{MinLat: 10, MaxLat: 5}}, field: "bbox"},
+		{name: "fuzzy prefix search", query: HotelQuery{Search: "hil", SearchMode: SearchPrefix, Fuzzy: true}, field: "fuzzy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateHotelQuery(v, tt.query)
+
+			if tt.field == "" {
+				if !v.Valid() {
+					t.Errorf("expected no errors, got %v", v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.field]; !ok {
+				t.Errorf("expected an error for %s, got %v", tt.field, v.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateBBox(t *testing.T) {
+	tests := []struct {
+		name  string
+		bbox  BBox
+		valid bool
+	}{
+		{name: "valid", bbox: BBox{MinLng: 7.2, MinLat: 43.6, MaxLng: 7.3, MaxLat: 43.8}, valid: true},
+		{name: "crosses the antimeridian", bbox: BBox{MinLng: 170, MinLat: -20, MaxLng: -170, MaxLat: -10}, valid: true},
+		{name: "longitude out of range", bbox: BBox{MinLng: -181, MinLat: 0, MaxLng: 0, MaxLat: 1}},
+		{name: "latitude out of range", bbox: BBox{MinLng: 0, MinLat: 0, MaxLng: 1, MaxLat: 91}},
+		{name: "latitudes reversed", bbox: BBox{MinLng: 0, MinLat: 10, MaxLng: 1, MaxLat: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateBBox(v, tt.bbox)
+
+			if v.Valid() != tt.valid {
+				t.Errorf("expected valid=%v, got errors %v", tt.valid, v.Errors)
+			}
+		})
+	}
+}
+
+func TestHotel_DiffCoordinates(t *testing.T) {
+	lat, lng := 43.695, 7.265
+	moved := 43.696
+
+	old := &Hotel{HotelID: 1, Latitude: &lat, Longitude: &lng}
+
+	if changes := old.Diff(&Hotel{HotelID: 1, Latitude: &lat, Longitude: &lng}); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+
+	changes := old.Diff(&Hotel{HotelID: 1, Latitude: &moved})
+	if len(changes) != 2 {
+		t.Fatalf("expected latitude and longitude to change, got %+v", changes)
+	}
+	if changes[0] != (FieldChange{Field: "latitude", OldValue: "43.695000", NewValue: "43.696000"}) {
+		t.Errorf("unexpected latitude change: %+v", changes[0])
+	}
+	if changes[1] != (FieldChange{Field: "longitude", OldValue: "7.265000", NewValue: ""}) {
+		t.Errorf("unexpected longitude change: %+v", changes[1])
+	}
+}
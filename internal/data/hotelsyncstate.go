@@ -0,0 +1,121 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// HotelSyncState is what the sync job remembers about a hotel between runs
+// so it can fetch only the newest reviews instead of the full history.
+//
+// ReviewCountOffset is the difference between the review_count Cupid
+// reported and the number of reviews stored after the last full resync. The
+// two rarely match exactly, but as long as incremental syncs keep up the
+// difference stays the same; when it moves, reviews were missed or removed
+// upstream and FullResyncDue is set.
+type HotelSyncState struct {
+	HotelID           int
+	NewestReviewAt    time.Time
+	ReviewCountOffset int
+	FullResyncDue     bool
+	LastFullSyncAt    time.Time
+	UpdatedAt         time.Time
+}
+
+type HotelSyncStateModel struct {
+	DB *sql.DB
+}
+
+// Get returns the sync state of a hotel, or ErrRecordNotFound if it has never
+// had a full resync. NewestReviewAt is zero when the hotel has no reviews.
+func (m HotelSyncStateModel) Get(hotelID int) (*HotelSyncState, error) {
+	query := `
+		SELECT hotel_id, newest_review_at, review_count_offset, full_resync_due, last_full_sync_at, updated_at
+		FROM hotel_sync_state
+		WHERE hotel_id = $1`
+
+	var state HotelSyncState
+	var newest, lastFull sql.NullTime
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, hotelID).Scan(
+		&state.HotelID,
+		&newest,
+		&state.ReviewCountOffset,
+		&state.FullResyncDue,
+		&lastFull,
+		&state.UpdatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	state.NewestReviewAt = newest.Time
+	state.LastFullSyncAt = lastFull.Time
+
+	return &state, nil
+}
+
+// RecordFullSyncTx records, as part of tx, that the full review history of a
+// hotel has just been written. It must run after the reviews are written so
+// the newest review date and offset reflect them.
+func (m HotelSyncStateModel) RecordFullSyncTx(tx *sql.Tx, hotelID int, reviewCount int) error {
+	query := `
+		INSERT INTO hotel_sync_state (hotel_id, newest_review_at, review_count_offset, full_resync_due, last_full_sync_at, updated_at)
+		SELECT $1, max(date), $2 - count(*), false, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM reviews
+		WHERE hotel_id = $1
+		ON CONFLICT (hotel_id) DO UPDATE SET
+			newest_review_at = EXCLUDED.newest_review_at,
+			review_count_offset = EXCLUDED.review_count_offset,
+			full_resync_due = false,
+			last_full_sync_at = EXCLUDED.last_full_sync_at,
+			updated_at = EXCLUDED.updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, hotelID, reviewCount)
+	return err
+}
+
+// RecordIncrementalSyncTx records, as part of tx, that the newest reviews of
+// a hotel have just been written, and flags a full resync if the stored
+// review count no longer tracks the review_count Cupid reported. It reports
+// whether a full resync is now due.
+func (m HotelSyncStateModel) RecordIncrementalSyncTx(tx *sql.Tx, hotelID int, reviewCount int) (bool, error) {
+	query := `
+		UPDATE hotel_sync_state AS s
+		SET newest_review_at = stored.newest,
+			full_resync_due = s.full_resync_due OR $2 - stored.reviews <> s.review_count_offset,
+			updated_at = CURRENT_TIMESTAMP
+		FROM (SELECT max(date) AS newest, count(*) AS reviews FROM reviews WHERE hotel_id = $1) AS stored
+		WHERE s.hotel_id = $1
+		RETURNING s.full_resync_due`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var due bool
+	err := tx.QueryRowContext(ctx, query, hotelID, reviewCount).Scan(&due)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, ErrRecordNotFound
+		default:
+			return false, err
+		}
+	}
+
+	return due, nil
+}
@@ -0,0 +1,114 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHotelSyncStateModel_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := HotelSyncStateModel{DB: db}
+
+	columns := []string{"hotel_id", "newest_review_at", "review_count_offset", "full_resync_due", "last_full_sync_at", "updated_at"}
+	newest := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_sync_state WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(123, newest, 4, false, now, now))
+
+	state, err := model.Get(123)
+	if err != nil {
+		t.Fatalf("error was not expected while getting sync state: %s", err)
+	}
+	if !state.NewestReviewAt.Equal(newest) || state.ReviewCountOffset != 4 || !state.LastFullSyncAt.Equal(now) {
+		t.Errorf("unexpected state: %+v", state)
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_sync_state WHERE hotel_id = \$1`).
+		WithArgs(456).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(456, nil, 0, true, nil, now))
+
+	state, err = model.Get(456)
+	if err != nil {
+		t.Fatalf("error was not expected while getting sync state: %s", err)
+	}
+	if !state.NewestReviewAt.IsZero() || !state.LastFullSyncAt.IsZero() || !state.FullResyncDue {
+		t.Errorf("expected NULL timestamps to read as zero, got %+v", state)
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM hotel_sync_state WHERE hotel_id = \$1`).
+		WithArgs(789).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = model.Get(789)
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHotelSyncStateModel_RecordTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := HotelSyncStateModel{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO hotel_sync_state (.+) SELECT \$1, max\(date\), \$2 - count\(\*\), (.+) FROM reviews WHERE hotel_id = \$1 ON CONFLICT \(hotel_id\) DO UPDATE`).
+		WithArgs(123, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE hotel_sync_state AS s SET (.+) FROM \(SELECT max\(date\) AS newest, count\(\*\) AS reviews FROM reviews WHERE hotel_id = \$1\) AS stored WHERE s.hotel_id = \$1 RETURNING s.full_resync_due`).
+		WithArgs(123, 12).
+		WillReturnRows(sqlmock.NewRows([]string{"full_resync_due"}).AddRow(true))
+	mock.ExpectQuery(`UPDATE hotel_sync_state`).
+		WithArgs(456, 1).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = model.RecordFullSyncTx(tx, 123, 10)
+	if err != nil {
+		t.Errorf("error was not expected while recording a full sync: %s", err)
+	}
+
+	due, err := model.RecordIncrementalSyncTx(tx, 123, 12)
+	if err != nil {
+		t.Errorf("error was not expected while recording an incremental sync: %s", err)
+	}
+	if !due {
+		t.Error("expected a full resync to be due")
+	}
+
+	_, err = model.RecordIncrementalSyncTx(tx, 456, 1)
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for a hotel without state, got %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
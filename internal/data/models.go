@@ -1,20 +1,58 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 )
 
 var ErrRecordNotFound = errors.New("record not found")
 
+// Querier is the subset of *sql.DB and *sql.Tx the models use, so the same
+// query code can run inside or outside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// WithTx runs fn inside a transaction. The transaction is committed if fn
+// returns nil and rolled back otherwise, or if ctx is cancelled first.
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 type Models struct {
-	Hotels  HotelModel
-	Reviews ReviewModel
+	Hotels         HotelModel
+	HotelChanges   HotelChangeModel
+	HotelDetails   HotelDetailsModel
+	HotelSyncState HotelSyncStateModel
+	Reviews        ReviewModel
+	SyncFailures   SyncFailureModel
+	SyncRuns       SyncRunModel
+	TrackedHotels  TrackedHotelModel
 }
 
 func NewModels(db *sql.DB) *Models {
 	return &Models{
-		Hotels:  HotelModel{DB: db},
-		Reviews: ReviewModel{DB: db},
+		Hotels:         HotelModel{DB: db},
+		HotelChanges:   HotelChangeModel{DB: db},
+		HotelDetails:   HotelDetailsModel{DB: db},
+		HotelSyncState: HotelSyncStateModel{DB: db},
+		Reviews:        ReviewModel{DB: db},
+		SyncFailures:   SyncFailureModel{DB: db},
+		SyncRuns:       SyncRunModel{DB: db},
+		TrackedHotels:  TrackedHotelModel{DB: db},
 	}
 }
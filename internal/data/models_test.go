@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestWithTx_Commit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	models := NewModels(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO hotels`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	mock.ExpectQuery(`INSERT INTO reviews`).
+		WithArgs(123, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "Jane", sqlmock.AnyArg(), "Lovely", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hotel_id", "created_at", "inserted"}).AddRow(1, 123, time.Now(), true))
+	mock.ExpectCommit()
+
+	var inserted bool
+	err = WithTx(context.Background(), db, func(tx *sql.Tx) error {
+		_, err := models.Hotels.UpsertTx(tx, &Hotel{HotelID: 123})
+		if err != nil {
+			return err
+		}
+
+		inserted, err = models.Reviews.UpsertTx(tx, 123, &Review{Name: "Jane", Headline: "Lovely"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error was not expected while running the transaction: %s", err)
+	}
+
+	if !inserted {
+		t.Error("expected the review to be reported as inserted")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWithTx_Rollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	models := NewModels(db)
+	reviewErr := errors.New("value too long")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO hotels`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	mock.ExpectQuery(`INSERT INTO reviews`).
+		WillReturnError(reviewErr)
+	mock.ExpectRollback()
+
+	err = WithTx(context.Background(), db, func(tx *sql.Tx) error {
+		_, err := models.Hotels.UpsertTx(tx, &Hotel{HotelID: 123})
+		if err != nil {
+			return err
+		}
+
+		_, err = models.Reviews.UpsertTx(tx, 123, &Review{Name: "Jane"})
+		return err
+	})
+	if !errors.Is(err, reviewErr) {
+		t.Errorf("expected the review error to be returned, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWithTx_BeginError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+
+	called := false
+	err = WithTx(context.Background(), db, func(tx *sql.Tx) error {
+		called = true
+		return nil
+	})
+	if err == nil {
+		t.Error("expected an error when the transaction cannot be started")
+	}
+	if called {
+		t.Error("expected fn not to be called")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
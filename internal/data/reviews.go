@@ -5,7 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Review struct {
@@ -22,6 +26,68 @@ type Review struct {
 	Cons         string    `json:"cons"`
 	Source       string    `json:"source"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	// Highlights holds snippets of the pros and cons with the search terms
+	// marked, set by GetAll for the fields that matched a search.
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+var reviewDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// Key identifies a review the same way the reviews_unique_key constraint
+// does. Dates are normalised so a value read back from the database matches
+// the string it was originally stored from.
+func (r *Review) Key() string {
+	date := r.Date
+	if t, ok := r.ParseDate(); ok {
+		date = t.Format("2006-01-02T15:04:05")
+	}
+
+	return strings.Join([]string{r.Name, date, r.Headline}, "\x00")
+}
+
+// ParseDate parses Date in any of the layouts Cupid and the database use. It
+// reports false if the date is missing or not recognised.
+func (r *Review) ParseDate() (time.Time, bool) {
+	for _, layout := range reviewDateLayouts {
+		if t, err := time.Parse(layout, r.Date); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Diff lists the content fields that differ between r and other, with r as
+// the old and other as the new value. Fields that are part of Key are not
+// compared.
+func (r *Review) Diff(other *Review) []FieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"average_score", strconv.Itoa(r.AverageScore), strconv.Itoa(other.AverageScore)},
+		{"country", r.Country, other.Country},
+		{"type", r.Type, other.Type},
+		{"language", strings.TrimSpace(r.Language), strings.TrimSpace(other.Language)},
+		{"pros", r.Pros, other.Pros},
+		{"cons", r.Cons, other.Cons},
+		{"source", r.Source, other.Source},
+	}
+
+	var changes []FieldChange
+	for _, f := range fields {
+		if f.old != f.new {
+			changes = append(changes, FieldChange{Field: f.name, OldValue: f.old, NewValue: f.new})
+		}
+	}
+
+	return changes
 }
 
 type ReviewModel struct {
@@ -59,7 +125,18 @@ func (r ReviewModel) Insert(hotelID int, review *Review) error {
 	return r.DB.QueryRowContext(ctx, query, args...).Scan(&review.ID, &review.HotelID, &review.CreatedAt)
 }
 
-func (r ReviewModel) Upsert(hotelID int, review *Review) error {
+// Upsert inserts review or updates the existing row with the same unique key.
+// It reports whether a new row was inserted.
+func (r ReviewModel) Upsert(hotelID int, review *Review) (bool, error) {
+	return upsertReview(r.DB, hotelID, review)
+}
+
+// UpsertTx is Upsert run as part of tx.
+func (r ReviewModel) UpsertTx(tx *sql.Tx, hotelID int, review *Review) (bool, error) {
+	return upsertReview(tx, hotelID, review)
+}
+
+func upsertReview(q Querier, hotelID int, review *Review) (bool, error) {
 	query := `
 		INSERT INTO reviews (hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
@@ -71,7 +148,7 @@ func (r ReviewModel) Upsert(hotelID int, review *Review) error {
 			pros = EXCLUDED.pros,
 			cons = EXCLUDED.cons,
 			source = EXCLUDED.source
-		RETURNING id, hotel_id, created_at
+		RETURNING id, hotel_id, created_at, (xmax = 0) AS inserted
 	`
 
 	args := []any{
@@ -91,7 +168,180 @@ func (r ReviewModel) Upsert(hotelID int, review *Review) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return r.DB.QueryRowContext(ctx, query, args...).Scan(&review.ID, &review.HotelID, &review.CreatedAt)
+	var inserted bool
+	err := q.QueryRowContext(ctx, query, args...).Scan(&review.ID, &review.HotelID, &review.CreatedAt, &inserted)
+
+	return inserted, err
+}
+
+// reviewBatchSize is the number of rows per INSERT statement in
+// UpsertBatch, keeping each statement well under Postgres' 65535 parameter
+// limit.
+const reviewBatchSize = 1000
+
+// BatchResult summarises a bulk upsert. IDs holds the id of every review
+// that was written, in no particular order.
+type BatchResult struct {
+	Inserted int
+	Updated  int
+	IDs      []int
+}
+
+// UpsertBatch upserts reviews using multi-row INSERT ... ON CONFLICT
+// statements of up to reviewBatchSize rows each. Reviews sharing a key are
+// written once, with the last one winning, since a single statement cannot
+// update the same row twice.
+func (r ReviewModel) UpsertBatch(hotelID int, reviews []Review) (BatchResult, error) {
+	return upsertReviewBatch(r.DB, hotelID, reviews)
+}
+
+// UpsertBatchTx is UpsertBatch run as part of tx.
+func (r ReviewModel) UpsertBatchTx(tx *sql.Tx, hotelID int, reviews []Review) (BatchResult, error) {
+	return upsertReviewBatch(tx, hotelID, reviews)
+}
+
+func upsertReviewBatch(q Querier, hotelID int, reviews []Review) (BatchResult, error) {
+	reviews = dedupeReviews(reviews)
+	result := BatchResult{IDs: make([]int, 0, len(reviews))}
+
+	for start := 0; start < len(reviews); start += reviewBatchSize {
+		end := min(start+reviewBatchSize, len(reviews))
+
+		err := upsertReviewChunk(q, hotelID, reviews[start:end], &result)
+		if err != nil {
+			return BatchResult{}, fmt.Errorf("upserting reviews %d-%d of %d: %w", start+1, end, len(reviews), err)
+		}
+	}
+
+	return result, nil
+}
+
+func upsertReviewChunk(q Querier, hotelID int, reviews []Review, result *BatchResult) error {
+	const columns = 11
+
+	var values strings.Builder
+	args := make([]any, 0, len(reviews)*columns)
+
+	for i, review := range reviews {
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		values.WriteString("(")
+		for j := 1; j <= columns; j++ {
+			if j > 1 {
+				values.WriteString(", ")
+			}
+			values.WriteString("$")
+			values.WriteString(strconv.Itoa(i*columns + j))
+		}
+		values.WriteString(")")
+
+		args = append(args,
+			hotelID,
+			review.AverageScore,
+			review.Country,
+			review.Type,
+			review.Name,
+			review.Date,
+			review.Headline,
+			review.Language,
+			review.Pros,
+			review.Cons,
+			review.Source,
+		)
+	}
+
+	query := `
+		INSERT INTO reviews (hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source)
+		VALUES ` + values.String() + `
+		ON CONFLICT (hotel_id, name, date, headline) DO UPDATE SET
+			average_score = EXCLUDED.average_score,
+			country = EXCLUDED.country,
+			type = EXCLUDED.type,
+			language = EXCLUDED.language,
+			pros = EXCLUDED.pros,
+			cons = EXCLUDED.cons,
+			source = EXCLUDED.source
+		RETURNING id, (xmax = 0) AS inserted`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var inserted bool
+
+		if err := rows.Scan(&id, &inserted); err != nil {
+			return err
+		}
+
+		result.IDs = append(result.IDs, id)
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+
+	return rows.Err()
+}
+
+// DeleteExcept removes the reviews of a hotel whose ids are not in keep and
+// returns how many were deleted. It is used to drop reviews that are no
+// longer returned upstream after the current set has been upserted.
+func (r ReviewModel) DeleteExcept(hotelID int, keep []int) (int, error) {
+	return deleteReviewsExcept(r.DB, hotelID, keep)
+}
+
+// DeleteExceptTx is DeleteExcept run as part of tx.
+func (r ReviewModel) DeleteExceptTx(tx *sql.Tx, hotelID int, keep []int) (int, error) {
+	return deleteReviewsExcept(tx, hotelID, keep)
+}
+
+func deleteReviewsExcept(q Querier, hotelID int, keep []int) (int, error) {
+	query := `
+		DELETE FROM reviews
+		WHERE hotel_id = $1 AND NOT (id = ANY($2))`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := q.ExecContext(ctx, query, hotelID, pq.Array(keep))
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// dedupeReviews drops all but the last review for each key, keeping the
+// position of the first occurrence.
+func dedupeReviews(reviews []Review) []Review {
+	index := make(map[string]int, len(reviews))
+	unique := make([]Review, 0, len(reviews))
+
+	for _, review := range reviews {
+		key := review.Key()
+		if i, ok := index[key]; ok {
+			unique[i] = review
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, review)
+	}
+
+	return unique
 }
 
 func (r ReviewModel) Get(hotelID int64, id int64) (*Review, error) {
@@ -135,18 +385,33 @@ func (r ReviewModel) Get(hotelID int64, id int64) (*Review, error) {
 	return &review, nil
 }
 
-func (r ReviewModel) GetAll(hotelID int64, search string, filters Filters) ([]*Review, Metadata, error) {
+// GetAll lists a hotel's reviews, optionally matching search written in the
+// given search mode (one of SearchModes, or empty for a plain search).
+func (r ReviewModel) GetAll(hotelID int64, search, mode string, filters Filters) ([]*Review, Metadata, error) {
+	orderBy := fmt.Sprintf("%s %s", filters.sortColumn(), filters.sortDirection())
+	if filters.sortColumn() == "relevance" {
+		orderBy = relevanceOrder(reviewSearchQuery(mode))
+	}
+
+	var snippets string
+	if search != "" {
+		snippets = fmt.Sprintf(", %s, %s",
+			headline(reviewSearchConfig, "pros", reviewSearchQuery(mode), headlineFragments),
+			headline(reviewSearchConfig, "cons", reviewSearchQuery(mode), headlineFragments),
+		)
+	}
+
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at
+		SELECT count(*) OVER(), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at%s
 		FROM reviews
-		WHERE hotel_id = $1 AND (fts @@ plainto_tsquery('simple', $2) OR $2 = '')
-		ORDER BY %s %s, id ASC
-		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+		WHERE hotel_id = $1 AND (fts @@ %s OR $2 = '')
+		ORDER BY %s, id ASC
+		LIMIT $3 OFFSET $4`, snippets, reviewSearchQuery(mode), orderBy)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := r.DB.QueryContext(ctx, query, hotelID, search, filters.limit(), filters.offset())
+	rows, err := r.DB.QueryContext(ctx, query, hotelID, searchArg(search, mode), filters.limit(), filters.offset())
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -157,8 +422,9 @@ func (r ReviewModel) GetAll(hotelID int64, search string, filters Filters) ([]*R
 
 	for rows.Next() {
 		var review Review
+		var prosSnippet, consSnippet sql.NullString
 
-		err := rows.Scan(
+		dest := []any{
 			&totalRows,
 			&review.ID,
 			&review.HotelID,
@@ -173,10 +439,19 @@ func (r ReviewModel) GetAll(hotelID int64, search string, filters Filters) ([]*R
 			&review.Cons,
 			&review.Source,
 			&review.CreatedAt,
-		)
+		}
+		if search != "" {
+			dest = append(dest, &prosSnippet, &consSnippet)
+		}
+
+		err := rows.Scan(dest...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
+		review.Highlights = highlights(map[string]sql.NullString{
+			"pros": prosSnippet,
+			"cons": consSnippet,
+		})
 		reviews = append(reviews, &review)
 	}
 
@@ -188,3 +463,53 @@ func (r ReviewModel) GetAll(hotelID int64, search string, filters Filters) ([]*R
 
 	return reviews, metadata, nil
 }
+
+// GetAllForHotel returns every stored review of a hotel, unpaginated.
+func (r ReviewModel) GetAllForHotel(hotelID int) ([]*Review, error) {
+	query := `
+		SELECT id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at
+		FROM reviews
+		WHERE hotel_id = $1
+		ORDER BY id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, query, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*Review
+
+	for rows.Next() {
+		var review Review
+
+		err := rows.Scan(
+			&review.ID,
+			&review.HotelID,
+			&review.AverageScore,
+			&review.Country,
+			&review.Type,
+			&review.Name,
+			&review.Date,
+			&review.Headline,
+			&review.Language,
+			&review.Pros,
+			&review.Cons,
+			&review.Source,
+			&review.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, &review)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
@@ -2,11 +2,17 @@ package data
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/lib/pq"
 )
 
 func TestReviewModel_Insert(t *testing.T) {
@@ -305,6 +311,7 @@ func TestReviewModel_GetAll(t *testing.T) {
 	rows := sqlmock.NewRows([]string{
 		"count", "id", "hotel_id", "average_score", "country", "type", "name",
 		"date", "headline", "language", "pros", "cons", "source", "created_at",
+		"pros_snippet", "cons_snippet",
 	})
 
 	for _, review := range expectedReviews {
@@ -313,14 +320,15 @@ func TestReviewModel_GetAll(t *testing.T) {
 			review.Country, review.Type, review.Name, review.Date,
 			review.Headline, review.Language, review.Pros, review.Cons,
 			review.Source, review.CreatedAt,
+			strings.Replace(review.Pros, "location", "<mark>location</mark>", 1), review.Cons,
 		)
 	}
 
-	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at, ts_headline\(review_search_config\(language\), coalesce\(pros, ''\), plainto_tsquery\(review_search_config\(language\), \$2\), (.+)\), ts_headline\(review_search_config\(language\), coalesce\(cons, ''\), (.+)\) FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 		WithArgs(hotelID, "test search", 20, 0).
 		WillReturnRows(rows)
 
-	reviews, metadata, err := reviewModel.GetAll(hotelID, "test search", filters)
+	reviews, metadata, err := reviewModel.GetAll(hotelID, "test search", "", filters)
 
 	if err != nil {
 		t.Errorf("error was not expected while getting all reviews: %s", err)
@@ -351,6 +359,14 @@ func TestReviewModel_GetAll(t *testing.T) {
 		t.Errorf("expected first review Name to be %s, got %s", expectedReviews[0].Name, reviews[0].Name)
 	}
 
+	// Only snippets that contain a match are returned.
+	if len(reviews[1].Highlights) != 1 || reviews[1].Highlights["pros"] != "Great <mark>location</mark>, amazing breakfast" {
+		t.Errorf("expected only the pros to be highlighted, got %v", reviews[1].Highlights)
+	}
+	if reviews[0].Highlights != nil {
+		t.Errorf("expected no highlights without a match, got %v", reviews[0].Highlights)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
@@ -379,11 +395,11 @@ func TestReviewModel_GetAll_EmptyResult(t *testing.T) {
 		"date", "headline", "language", "pros", "cons", "source", "created_at",
 	})
 
-	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 		WithArgs(hotelID, "", 20, 0).
 		WillReturnRows(rows)
 
-	reviews, metadata, err := reviewModel.GetAll(hotelID, "", filters)
+	reviews, metadata, err := reviewModel.GetAll(hotelID, "", "", filters)
 
 	if err != nil {
 		t.Errorf("error was not expected while getting all reviews: %s", err)
@@ -420,11 +436,11 @@ func TestReviewModel_GetAll_DatabaseError(t *testing.T) {
 
 	hotelID := int64(123)
 
-	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 		WithArgs(hotelID, "", 20, 0).
 		WillReturnError(sql.ErrConnDone)
 
-	reviews, metadata, err := reviewModel.GetAll(hotelID, "", filters)
+	reviews, metadata, err := reviewModel.GetAll(hotelID, "", "", filters)
 
 	if err == nil {
 		t.Error("expected error, but got none")
@@ -474,11 +490,11 @@ func TestReviewModel_GetAll_RowScanError(t *testing.T) {
 		"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid",
 	)
 
-	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\('simple', \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source, created_at FROM reviews WHERE hotel_id = \$1 AND \(fts @@ plainto_tsquery\(review_search_config\(language\), \$2\) OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
 		WithArgs(hotelID, "", 20, 0).
 		WillReturnRows(rows)
 
-	reviews, metadata, err := reviewModel.GetAll(hotelID, "", filters)
+	reviews, metadata, err := reviewModel.GetAll(hotelID, "", "", filters)
 
 	if err == nil {
 		t.Error("expected error, but got none")
@@ -556,15 +572,19 @@ func TestReviewModel_Upsert(t *testing.T) {
 			review.Cons,
 			review.Source,
 		).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "hotel_id", "created_at"}).
-			AddRow(expectedID, hotelID, expectedCreatedAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hotel_id", "created_at", "inserted"}).
+			AddRow(expectedID, hotelID, expectedCreatedAt, true))
 
-	err = reviewModel.Upsert(hotelID, review)
+	inserted, err := reviewModel.Upsert(hotelID, review)
 
 	if err != nil {
 		t.Errorf("error was not expected while upserting review: %s", err)
 	}
 
+	if !inserted {
+		t.Error("expected review to be reported as inserted")
+	}
+
 	if review.ID != expectedID {
 		t.Errorf("expected ID to be %v, got %v", expectedID, review.ID)
 	}
@@ -604,7 +624,7 @@ func TestReviewModel_Upsert_Error(t *testing.T) {
 			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(sql.ErrConnDone)
 
-	err = reviewModel.Upsert(hotelID, review)
+	_, err = reviewModel.Upsert(hotelID, review)
 
 	if err == nil {
 		t.Error("expected error, but got none")
@@ -619,6 +639,134 @@ func TestReviewModel_Upsert_Error(t *testing.T) {
 	}
 }
 
+func TestReview_Key(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  Review
+		equal bool
+	}{
+		{
+			name:  "stored timestamp matches upstream string",
+			a:     Review{Name: "Jane", Date: "2024-01-15T10:00:00Z", Headline: "Lovely"},
+			b:     Review{Name: "Jane", Date: "2024-01-15 10:00:00", Headline: "Lovely"},
+			equal: true,
+		},
+		{
+			name:  "date only",
+			a:     Review{Name: "Jane", Date: "2024-01-15T00:00:00Z", Headline: "Lovely"},
+			b:     Review{Name: "Jane", Date: "2024-01-15", Headline: "Lovely"},
+			equal: true,
+		},
+		{
+			name:  "different headline",
+			a:     Review{Name: "Jane", Date: "2024-01-15", Headline: "Lovely"},
+			b:     Review{Name: "Jane", Date: "2024-01-15", Headline: "Awful"},
+			equal: false,
+		},
+		{
+			name:  "unparseable dates compared verbatim",
+			a:     Review{Name: "Jane", Date: "yesterday", Headline: "Lovely"},
+			b:     Review{Name: "Jane", Date: "yesterday", Headline: "Lovely"},
+			equal: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Key() == tt.b.Key(); got != tt.equal {
+				t.Errorf("expected keys equal to be %v, got %q and %q", tt.equal, tt.a.Key(), tt.b.Key())
+			}
+		})
+	}
+}
+
+func TestReview_Diff(t *testing.T) {
+	old := &Review{AverageScore: 8, Language: "en", Pros: "Quiet", Cons: "Small"}
+	updated := &Review{AverageScore: 9, Language: "en", Pros: "Quiet", Cons: "Tiny"}
+
+	expected := []FieldChange{
+		{Field: "average_score", OldValue: "8", NewValue: "9"},
+		{Field: "cons", OldValue: "Small", NewValue: "Tiny"},
+	}
+
+	if changes := old.Diff(updated); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, changes)
+	}
+}
+
+func TestReviewModel_GetAllForHotel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reviewModel := ReviewModel{DB: db}
+
+	rows := sqlmock.NewRows([]string{
+		"id", "hotel_id", "average_score", "country", "type", "name",
+		"date", "headline", "language", "pros", "cons", "source", "created_at",
+	}).
+		AddRow(1, 123, 8, "USA", "Business", "John Doe", "2024-01-15", "Great stay!", "en", "Clean rooms", "Limited parking", "booking.com", time.Now()).
+		AddRow(2, 123, 6, "UK", "Leisure", "Jane Doe", "2024-02-01", "Okay", "en", "Location", "Noise", "expedia", time.Now())
+
+	mock.ExpectQuery(`SELECT id, hotel_id, average_score, (.+) FROM reviews WHERE hotel_id = \$1 ORDER BY id ASC`).
+		WithArgs(123).
+		WillReturnRows(rows)
+
+	reviews, err := reviewModel.GetAllForHotel(123)
+	if err != nil {
+		t.Fatalf("error was not expected while getting reviews: %s", err)
+	}
+
+	if len(reviews) != 2 {
+		t.Fatalf("expected 2 reviews, got %d", len(reviews))
+	}
+
+	if reviews[1].Name != "Jane Doe" {
+		t.Errorf("expected second review by Jane Doe, got %s", reviews[1].Name)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReviewModel_DeleteExcept(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reviewModel := ReviewModel{DB: db}
+
+	mock.ExpectExec(`DELETE FROM reviews WHERE hotel_id = \$1 AND NOT \(id = ANY\(\$2\)\)`).
+		WithArgs(123, "{4,5,9}").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	removed, err := reviewModel.DeleteExcept(123, []int{4, 5, 9})
+	if err != nil {
+		t.Fatalf("error was not expected while deleting reviews: %s", err)
+	}
+
+	if removed != 2 {
+		t.Errorf("expected 2 removed reviews, got %d", removed)
+	}
+
+	mock.ExpectExec(`DELETE FROM reviews`).
+		WillReturnError(sql.ErrConnDone)
+
+	_, err = reviewModel.DeleteExcept(123, []int{4})
+	if err != sql.ErrConnDone {
+		t.Errorf("expected error to be %v, got %v", sql.ErrConnDone, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 // Benchmark tests
 func BenchmarkReviewModel_Get(b *testing.B) {
 	db, mock, err := sqlmock.New()
@@ -692,3 +840,282 @@ func BenchmarkReviewModel_Insert(b *testing.B) {
 		}
 	}
 }
+
+func TestReviewModel_UpsertBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reviewModel := ReviewModel{DB: db}
+
+	reviews := []Review{
+		{Name: "Jane", Date: "2023-01-15", Headline: "Lovely", AverageScore: 8},
+		{Name: "John", Date: "2023-01-16", Headline: "Fine", AverageScore: 6},
+		{Name: "Jane", Date: "2023-01-15T00:00:00Z", Headline: "Lovely", AverageScore: 9},
+	}
+
+	mock.ExpectQuery(`INSERT INTO reviews \(hotel_id, average_score, country, type, name, date, headline, language, pros, cons, source\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11\), \(\$12, .*\$22\) ON CONFLICT \(hotel_id, name, date, headline\) DO UPDATE SET .* RETURNING id, \(xmax = 0\) AS inserted`).
+		WithArgs(
+			123, 9, "", "", "Jane", "2023-01-15T00:00:00Z", "Lovely", "", "", "", "",
+			123, 6, "", "", "John", "2023-01-16", "Fine", "", "", "", "",
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "inserted"}).AddRow(4, false).AddRow(5, true))
+
+	result, err := reviewModel.UpsertBatch(123, reviews)
+	if err != nil {
+		t.Fatalf("error was not expected while upserting reviews: %s", err)
+	}
+
+	if result.Inserted != 1 || result.Updated != 1 {
+		t.Errorf("expected 1 inserted and 1 updated, got %+v", result)
+	}
+
+	if !reflect.DeepEqual(result.IDs, []int{4, 5}) {
+		t.Errorf("expected IDs [4 5], got %v", result.IDs)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReviewModel_UpsertBatch_Chunks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reviewModel := ReviewModel{DB: db}
+
+	reviews := make([]Review, 2500)
+	for i := range reviews {
+		reviews[i] = Review{Name: fmt.Sprintf("guest %d", i), Headline: "Nice"}
+	}
+
+	for _, size := range []int{1000, 1000, 500} {
+		rows := sqlmock.NewRows([]string{"id", "inserted"})
+		for i := range size {
+			rows.AddRow(i+1, true)
+		}
+		mock.ExpectQuery(`INSERT INTO reviews`).WillReturnRows(rows)
+	}
+
+	result, err := reviewModel.UpsertBatch(123, reviews)
+	if err != nil {
+		t.Fatalf("error was not expected while upserting reviews: %s", err)
+	}
+
+	if result.Inserted != 2500 || len(result.IDs) != 2500 {
+		t.Errorf("expected 2500 inserted reviews, got %d inserted and %d IDs", result.Inserted, len(result.IDs))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReviewModel_UpsertBatch_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	result, err := ReviewModel{DB: db}.UpsertBatch(123, nil)
+	if err != nil {
+		t.Fatalf("error was not expected for an empty batch: %s", err)
+	}
+
+	if result.Inserted != 0 || result.Updated != 0 || len(result.IDs) != 0 {
+		t.Errorf("expected an empty result, got %+v", result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReviewModel_UpsertBatch_Error(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`INSERT INTO reviews`).WillReturnError(sql.ErrConnDone)
+
+	_, err = ReviewModel{DB: db}.UpsertBatch(123, []Review{{Name: "Jane"}})
+	if !errors.Is(err, sql.ErrConnDone) {
+		t.Errorf("expected error to wrap %v, got %v", sql.ErrConnDone, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// Benchmark tests
+
+// benchmarkReviews returns n reviews with distinct keys.
+func benchmarkReviews(n int) []Review {
+	reviews := make([]Review, n)
+	for i := range reviews {
+		reviews[i] = Review{
+			AverageScore: 8,
+			Country:      "fr",
+			Type:         "couple",
+			Name:         fmt.Sprintf("guest %d", i),
+			Date:         "2023-01-15 10:00:00",
+			Headline:     "Lovely stay",
+			Language:     "en",
+			Pros:         "Great location, friendly staff",
+			Cons:         "Small rooms",
+			Source:       "cupid",
+		}
+	}
+	return reviews
+}
+
+// The sqlmock benchmarks add a fixed delay to every statement to stand in
+// for the network round trip to Postgres, which is what the batch path
+// saves. BenchmarkReviewUpsertPostgres measures the real thing.
+const simulatedRoundTrip = 200 * time.Microsecond
+
+func BenchmarkReviewUpsert_PerRow(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+
+			reviewModel := ReviewModel{DB: db}
+			reviews := benchmarkReviews(n)
+
+			b.ResetTimer()
+
+			for range b.N {
+				b.StopTimer()
+				for range n {
+					mock.ExpectQuery(`INSERT INTO reviews`).
+						WillDelayFor(simulatedRoundTrip).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "hotel_id", "created_at", "inserted"}).AddRow(1, 123, time.Now(), true))
+				}
+				b.StartTimer()
+
+				for i := range reviews {
+					if _, err := reviewModel.Upsert(123, &reviews[i]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+
+			b.ReportMetric(float64(b.N*n)/b.Elapsed().Seconds(), "reviews/s")
+		})
+	}
+}
+
+func BenchmarkReviewUpsert_Batch(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+
+			reviewModel := ReviewModel{DB: db}
+			reviews := benchmarkReviews(n)
+
+			b.ResetTimer()
+
+			for range b.N {
+				b.StopTimer()
+				rows := sqlmock.NewRows([]string{"id", "inserted"})
+				for i := range n {
+					rows.AddRow(i+1, true)
+				}
+				mock.ExpectQuery(`INSERT INTO reviews`).
+					WillDelayFor(simulatedRoundTrip).
+					WillReturnRows(rows)
+				b.StartTimer()
+
+				if _, err := reviewModel.UpsertBatch(123, reviews); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(b.N*n)/b.Elapsed().Seconds(), "reviews/s")
+		})
+	}
+}
+
+// BenchmarkReviewUpsertPostgres compares both paths against a migrated
+// database given by NUITEE_TEST_DB_DSN. Every iteration runs in a
+// transaction that is rolled back.
+func BenchmarkReviewUpsertPostgres(b *testing.B) {
+	dsn := os.Getenv("NUITEE_TEST_DB_DSN")
+	if dsn == "" {
+		b.Skip("NUITEE_TEST_DB_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	models := NewModels(db)
+	hotel := &Hotel{HotelID: 2147483000, HotelName: "Benchmark Hotel"}
+
+	paths := []struct {
+		name   string
+		upsert func(tx *sql.Tx, reviews []Review) error
+	}{
+		{"PerRow", func(tx *sql.Tx, reviews []Review) error {
+			for i := range reviews {
+				if _, err := models.Reviews.UpsertTx(tx, hotel.HotelID, &reviews[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"Batch", func(tx *sql.Tx, reviews []Review) error {
+			_, err := models.Reviews.UpsertBatchTx(tx, hotel.HotelID, reviews)
+			return err
+		}},
+	}
+
+	for _, path := range paths {
+		for _, n := range []int{100, 1000, 10000} {
+			b.Run(fmt.Sprintf("%s/%d", path.name, n), func(b *testing.B) {
+				reviews := benchmarkReviews(n)
+
+				for range b.N {
+					tx, err := db.Begin()
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					if _, err := models.Hotels.UpsertTx(tx, hotel); err != nil {
+						tx.Rollback()
+						b.Fatal(err)
+					}
+
+					if err := path.upsert(tx, reviews); err != nil {
+						tx.Rollback()
+						b.Fatal(err)
+					}
+
+					tx.Rollback()
+				}
+
+				b.ReportMetric(float64(b.N*n)/b.Elapsed().Seconds(), "reviews/s")
+			})
+		}
+	}
+}
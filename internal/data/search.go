@@ -0,0 +1,167 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+// Search terms in highlighted snippets are wrapped in these tags. <mark> is
+// used rather than ts_headline's default <b> since hotel descriptions already
+// contain markup of their own.
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// Search modes select how a search string is turned into a tsquery.
+const (
+	// SearchPlain matches rows containing all of the words.
+	SearchPlain = "plain"
+	// SearchWebsearch accepts web search engine syntax: "quoted phrases",
+	// "or" between alternatives and -term to exclude a word.
+	SearchWebsearch = "websearch"
+	// SearchPrefix treats every word as a prefix, for type-ahead.
+	SearchPrefix = "prefix"
+)
+
+var SearchModes = []string{SearchPlain, SearchWebsearch, SearchPrefix}
+
+// maxSearchWords caps the words of a prefix search, each of which is a
+// separate index lookup.
+const maxSearchWords = 10
+
+func ValidateSearch(v *validator.Validator, search, mode string) {
+	v.Check(mode == "" || validator.PermittedValue(mode, SearchModes...), "q_mode", "must be one of "+strings.Join(SearchModes, ", "))
+	v.Check(len(search) <= 500, "search", "must not be more than 500 bytes long")
+
+	if search == "" {
+		return
+	}
+
+	switch mode {
+	case SearchWebsearch:
+		v.Check(strings.Count(search, `"`)%2 == 0, "search", "must not contain an unmatched quote")
+		v.Check(slices.ContainsFunc(strings.Fields(search), func(term string) bool {
+			return !strings.HasPrefix(term, "-") && !strings.EqualFold(term, "or") && len(searchWords(term)) > 0
+		}), "search", "must contain at least one term that is not excluded")
+	case SearchPrefix:
+		words := searchWords(search)
+		v.Check(len(words) > 0, "search", "must contain at least one word")
+		v.Check(len(words) <= maxSearchWords, "search", fmt.Sprintf("must not contain more than %d words", maxSearchWords))
+	}
+}
+
+// searchWords splits a search into its runs of letters and digits, the only
+// characters that reach to_tsquery in prefix mode, so its operators can never
+// be injected.
+func searchWords(search string) []string {
+	return strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchArg is the value bound for a search in the given mode. Prefix searches
+// are rewritten into to_tsquery syntax, "gra hot" becoming "gra:* & hot:*".
+func searchArg(search, mode string) string {
+	if mode != SearchPrefix {
+		return search
+	}
+
+	words := searchWords(search)
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// tsquery parses the search bound to param with the text search configuration
+// config, according to mode. An empty mode is a plain search.
+func tsquery(mode, config, param string) string {
+	switch mode {
+	case SearchWebsearch:
+		return fmt.Sprintf("websearch_to_tsquery(%s, %s)", config, param)
+	case SearchPrefix:
+		return fmt.Sprintf("to_tsquery(%s, %s)", config, param)
+	default:
+		return fmt.Sprintf("plainto_tsquery(%s, %s)", config, param)
+	}
+}
+
+// hotels.fts holds the name, city, country and description twice: unstemmed,
+// so proper nouns match exactly, and stemmed as English, the language Cupid
+// serves descriptions in. A search matches when all its words match either
+// half, so both halves must cover every field for searches that mix a place
+// with a description word, or exclude a place, to work.
+func hotelSearchQuery(mode string) string {
+	return "(" + tsquery(mode, "'simple'", "$1") + " || " + tsquery(mode, "'english'", "$1") + ")"
+}
+
+// hotelTrigramText is what fuzzy hotel searches are compared to by trigram
+// similarity. It must stay identical to the expression indexed by
+// idx_hotels_trgm (migration 000014) for the index to be used.
+const hotelTrigramText = "(hotel_name || ' ' || coalesce(city, ''))"
+
+// hotelTrigramMatch and hotelSimilarity use word similarity, so a search only
+// has to resemble part of the name and city: "Hiltn Pris" is close to the
+// "Hilton Paris" in "Hilton Paris Opera Paris". The match uses
+// pg_trgm.word_similarity_threshold, 0.6 by default.
+const (
+	hotelTrigramMatch = "$1 <% " + hotelTrigramText
+	hotelSimilarity   = "word_similarity($1, " + hotelTrigramText + ")"
+)
+
+// Each review is indexed with the text search configuration for its language,
+// falling back to 'simple' for languages Postgres has no stemmer for, and is
+// searched with the same configuration so both sides stem alike.
+const reviewSearchConfig = "review_search_config(language)"
+
+func reviewSearchQuery(mode string) string {
+	return tsquery(mode, reviewSearchConfig, "$2")
+}
+
+// relevanceOrder ranks rows by how closely their fts column matches tsquery,
+// best match first.
+func relevanceOrder(tsquery string) string {
+	return fmt.Sprintf("ts_rank_cd(fts, %s) DESC", tsquery)
+}
+
+// headline selects a ts_headline snippet of column, parsed with the text
+// search configuration config, around the terms of tsquery. A nil column
+// yields an empty snippet.
+func headline(config, column, tsquery, options string) string {
+	return fmt.Sprintf(
+		"ts_headline(%s, coalesce(%s, ''), %s, 'StartSel=%s, StopSel=%s, %s')",
+		config, column, tsquery, highlightStart, highlightStop, options,
+	)
+}
+
+// Options for headline: short fields such as names are highlighted whole,
+// long ones are cut down to the fragments around the matches.
+const (
+	headlineWhole     = "HighlightAll=true"
+	headlineFragments = "MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=\" … \""
+)
+
+// highlights keeps the snippets that contain a match, keyed by field name. It
+// returns nil when none do, so results without highlights omit the field.
+func highlights(snippets map[string]sql.NullString) map[string]string {
+	var matched map[string]string
+
+	for field, snippet := range snippets {
+		if !snippet.Valid || !strings.Contains(snippet.String, highlightStart) {
+			continue
+		}
+		if matched == nil {
+			matched = make(map[string]string, len(snippets))
+		}
+		matched[field] = snippet.String
+	}
+
+	return matched
+}
@@ -0,0 +1,243 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/JLL32/nuitee/internal/validator"
+	_ "github.com/lib/pq"
+)
+
+// TestSearchStemmingPostgres checks the text search configurations against a
+// migrated database given by NUITEE_TEST_DB_DSN. The stemming happens inside
+// Postgres, so a mocked database cannot show it.
+func TestSearchStemmingPostgres(t *testing.T) {
+	dsn := os.Getenv("NUITEE_TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("NUITEE_TEST_DB_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	models := NewModels(db)
+
+	hotel := &Hotel{
+		HotelID:     2147483001,
+		HotelName:   "Stemming Test Hotel",
+		Address:     Address{City: "Lisbon", Country: "pt"},
+		Description: "Spacious rooms overlooking the harbour",
+	}
+	if _, err := models.Hotels.Upsert(hotel); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM hotels WHERE hotel_id = $1`, hotel.HotelID)
+	})
+
+	reviews := []Review{
+		{Name: "Emma", Date: "2024-05-01 00:00:00", Headline: "Lovely stay", Language: "en", Pros: "The beds were comfortable"},
+		{Name: "Julien", Date: "2024-05-02 00:00:00", Headline: "Très bien", Language: "fr", Pros: "Chambres spacieuses et lumineuses"},
+		{Name: "Lukas", Date: "2024-05-03 00:00:00", Headline: "Gut", Language: "xx", Pros: "Rooms"},
+	}
+	for i := range reviews {
+		if _, err := models.Reviews.Upsert(hotel.HotelID, &reviews[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filters := Filters{Page: 1, PageSize: 100, Sort: "hotel_id", SortSafelist: []string{"hotel_id"}}
+
+	hotelTests := []struct {
+		search  string
+		mode    string
+		matches bool
+	}{
+		{search: "room", matches: true},
+		{search: "overlook", matches: true},
+		{search: "the rooms", matches: true},
+		{search: "stemming hotel", matches: true},
+		// A place and a description word match together, stemmed or not.
+		{search: "rooms lisbon", matches: true},
+		{search: "room lisbon", matches: true},
+		{search: "harbour pt", matches: true},
+		{search: `"spacious rooms" -cheap`, mode: SearchWebsearch, matches: true},
+		{search: `"rooms spacious"`, mode: SearchWebsearch, matches: false},
+		{search: "castle or harbour", mode: SearchWebsearch, matches: true},
+		{search: "room -harbour", mode: SearchWebsearch, matches: false},
+		// Excluding a place must hold in both halves of fts.
+		{search: "spacious -lisbon", mode: SearchWebsearch, matches: false},
+		{search: "spacious -pt", mode: SearchWebsearch, matches: false},
+		{search: "spacious -porto", mode: SearchWebsearch, matches: true},
+		{search: "stem hot", mode: SearchPrefix, matches: true},
+		{search: "harb", mode: SearchPrefix, matches: true},
+		{search: "harb", matches: false},
+	}
+
+	for _, tt := range hotelTests {
+		t.Run("hotels/"+tt.search, func(t *testing.T) {
+			hotels, _, err := models.Hotels.GetAll(HotelQuery{Search: tt.search, SearchMode: tt.mode}, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := slices.ContainsFunc(hotels, func(h *Hotel) bool { return h.HotelID == hotel.HotelID })
+			if found != tt.matches {
+				t.Errorf("expected match=%v for %q", tt.matches, tt.search)
+			}
+		})
+	}
+
+	reviewFilters := Filters{Page: 1, PageSize: 100, Sort: "id", SortSafelist: []string{"id"}}
+
+	reviewTests := []struct {
+		search   string
+		expected []string
+	}{
+		// English reviews are stemmed as English.
+		{search: "bed", expected: []string{"Emma"}},
+		// French reviews are stemmed as French, and searched the same way.
+		{search: "chambre spacieuse", expected: []string{"Julien"}},
+		// Unknown languages fall back to 'simple': only exact words match.
+		{search: "rooms", expected: []string{"Lukas"}},
+		{search: "room", expected: nil},
+	}
+
+	for _, tt := range reviewTests {
+		t.Run("reviews/"+tt.search, func(t *testing.T) {
+			found, _, err := models.Reviews.GetAll(int64(hotel.HotelID), tt.search, SearchPlain, reviewFilters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, review := range found {
+				names = append(names, review.Name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("expected %v for %q, got %v", tt.expected, tt.search, names)
+			}
+		})
+	}
+}
+
+// TestFuzzySearchPostgres checks trigram matching against a migrated database
+// given by NUITEE_TEST_DB_DSN.
+func TestFuzzySearchPostgres(t *testing.T) {
+	dsn := os.Getenv("NUITEE_TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("NUITEE_TEST_DB_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	models := NewModels(db)
+
+	hotel := &Hotel{
+		HotelID:   2147483002,
+		HotelName: "Hilton Paris Opera",
+		Address:   Address{City: "Paris", Country: "fr"},
+	}
+	if _, err := models.Hotels.Upsert(hotel); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM hotels WHERE hotel_id = $1`, hotel.HotelID)
+	})
+
+	filters := Filters{Page: 1, PageSize: 100, Sort: "relevance", SortSafelist: []string{"relevance"}}
+
+	tests := []struct {
+		search  string
+		fuzzy   bool
+		matches bool
+	}{
+		{search: "Hiltn Pris", fuzzy: false, matches: false},
+		{search: "Hiltn Pris", fuzzy: true, matches: true},
+		{search: "hilton opera", fuzzy: true, matches: true},
+		{search: "Ritz Madrid", fuzzy: true, matches: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/fuzzy=%v", tt.search, tt.fuzzy), func(t *testing.T) {
+			hotels, _, err := models.Hotels.GetAll(HotelQuery{Search: tt.search, Fuzzy: tt.fuzzy}, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			i := slices.IndexFunc(hotels, func(h *Hotel) bool { return h.HotelID == hotel.HotelID })
+			if (i >= 0) != tt.matches {
+				t.Fatalf("expected match=%v for %q", tt.matches, tt.search)
+			}
+			if i >= 0 && tt.fuzzy && (hotels[i].Similarity == nil || *hotels[i].Similarity <= 0) {
+				t.Errorf("expected a similarity score, got %v", hotels[i].Similarity)
+			}
+		})
+	}
+}
+
+func TestSearchArg(t *testing.T) {
+	tests := []struct {
+		search   string
+		mode     string
+		expected string
+	}{
+		{search: "grand hotel", mode: SearchPlain, expected: "grand hotel"},
+		{search: `"grand hotel" -cheap`, mode: SearchWebsearch, expected: `"grand hotel" -cheap`},
+		{search: "gra hot", mode: SearchPrefix, expected: "gra:* & hot:*"},
+		{search: "saint-tro", mode: SearchPrefix, expected: "saint:* & tro:*"},
+		{search: "l'hôt & !x:*", mode: SearchPrefix, expected: "l:* & hôt:* & x:*"},
+	}
+
+	for _, tt := range tests {
+		if got := searchArg(tt.search, tt.mode); got != tt.expected {
+			t.Errorf("searchArg(%q, %q) = %q, want %q", tt.search, tt.mode, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		search string
+		mode   string
+		field  string
+	}{
+		{name: "plain", search: "grand hotel", mode: SearchPlain},
+		{name: "empty mode is plain", search: "grand hotel"},
+		{name: "websearch", search: `"grand hotel" or palace -cheap`, mode: SearchWebsearch},
+		{name: "prefix", search: "gra", mode: SearchPrefix},
+		{name: "unknown mode", search: "grand", mode: "regex", field: "q_mode"},
+		{name: "unmatched quote", search: `"grand hotel`, mode: SearchWebsearch, field: "search"},
+		{name: "only exclusions", search: "-cheap -hostel", mode: SearchWebsearch, field: "search"},
+		{name: "prefix without words", search: "&!:*", mode: SearchPrefix, field: "search"},
+		{name: "too many prefix words", search: "a b c d e f g h i j k", mode: SearchPrefix, field: "search"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateSearch(v, tt.search, tt.mode)
+
+			if tt.field == "" {
+				if !v.Valid() {
+					t.Errorf("expected no errors, got %v", v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.field]; !ok {
+				t.Errorf("expected an error for %s, got %v", tt.field, v.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+// MaxSuggestions caps how many suggestions Suggest returns.
+const MaxSuggestions = 20
+
+// Suggestion is an autocomplete entry: a hotel, identified by HotelID, or a
+// destination, with Count the number of hotels it has. City and Country give
+// the context a hotel or city is in.
+type Suggestion struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	HotelID int    `json:"hotel_id,omitempty"`
+	City    string `json:"city,omitempty"`
+	Country string `json:"country,omitempty"`
+	Count   int    `json:"count,omitempty"`
+}
+
+func ValidateSuggest(v *validator.Validator, q string, limit int) {
+	words := searchWords(q)
+
+	v.Check(strings.TrimSpace(q) != "", "q", "must be provided")
+	v.Check(len(q) <= 100, "q", "must not be more than 100 bytes long")
+	v.Check(len(words) > 0, "q", "must contain at least one word")
+	v.Check(utf8.RuneCountInString(strings.Join(words, "")) >= 2, "q", "must contain at least 2 letters or digits")
+	v.Check(len(words) <= maxSearchWords, "q", fmt.Sprintf("must not contain more than %d words", maxSearchWords))
+
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= MaxSuggestions, "limit", fmt.Sprintf("must be a maximum of %d", MaxSuggestions))
+}
+
+// SuggestKey normalises q to the form Suggest ranks by, so queries that differ
+// only in case or spacing give the same suggestions and can share a cache
+// entry.
+func SuggestKey(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
+
+// Suggest returns up to limit hotels, cities and countries whose name has
+// words starting with the words of q, as typed into a search box.
+//
+// Candidates come from a single prefix search of hotels.fts, which holds the
+// name, city and country unstemmed and is GIN indexed. Each type is then
+// narrowed to the rows whose own name matches, so "paris" suggests the city
+// rather than every hotel in it. Suggestions whose name starts with q come
+// first, then the closest by trigram word similarity. Remaining ties list
+// destinations before hotels, the most popular first.
+func (h HotelModel) Suggest(q string, limit int) ([]*Suggestion, error) {
+	query := `
+		WITH matched AS (
+			SELECT hotel_id, hotel_name, coalesce(city, '') AS city, coalesce(country, '') AS country, review_count
+			FROM hotels
+			WHERE fts @@ to_tsquery('simple', $1)
+		), hotel_suggestions AS (
+			SELECT 'hotel' AS type, hotel_name AS text, hotel_id, city, country, NULL::integer AS count, coalesce(review_count, 0) AS popularity
+			FROM matched
+			WHERE to_tsvector('simple', hotel_name) @@ to_tsquery('simple', $1)
+			ORDER BY word_similarity($2, hotel_name) DESC, popularity DESC
+			LIMIT $3
+		), city_suggestions AS (
+			SELECT 'city' AS type, city AS text, NULL::integer AS hotel_id, NULL AS city, country, count(*)::integer AS count, count(*)::integer AS popularity
+			FROM matched
+			WHERE to_tsvector('simple', city) @@ to_tsquery('simple', $1)
+			GROUP BY city, country
+			ORDER BY count(*) DESC
+			LIMIT $3
+		), country_suggestions AS (
+			SELECT 'country' AS type, country AS text, NULL::integer AS hotel_id, NULL AS city, NULL AS country, count(*)::integer AS count, count(*)::integer AS popularity
+			FROM matched
+			WHERE to_tsvector('simple', country) @@ to_tsquery('simple', $1)
+			GROUP BY country
+			ORDER BY count(*) DESC
+			LIMIT $3
+		), suggestions AS (
+			SELECT * FROM hotel_suggestions
+			UNION ALL
+			SELECT * FROM city_suggestions
+			UNION ALL
+			SELECT * FROM country_suggestions
+		)
+		SELECT type, text, hotel_id, city, country, count
+		FROM suggestions
+		ORDER BY starts_with(lower(text), $2) DESC, word_similarity($2, text) DESC,
+			type = 'hotel' ASC, popularity DESC, text ASC
+		LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	key := SuggestKey(q)
+
+	rows, err := h.DB.QueryContext(ctx, query, searchArg(key, SearchPrefix), key, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []*Suggestion{}
+
+	for rows.Next() {
+		var suggestion Suggestion
+		var hotelID, count sql.NullInt64
+		var city, country sql.NullString
+
+		err := rows.Scan(
+			&suggestion.Type,
+			&suggestion.Text,
+			&hotelID,
+			&city,
+			&country,
+			&count,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestion.HotelID = int(hotelID.Int64)
+		suggestion.City = city.String
+		suggestion.Country = country.String
+		suggestion.Count = int(count.Int64)
+
+		suggestions = append(suggestions, &suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"database/sql"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+func TestHotelModel_Suggest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	hotelModel := HotelModel{DB: db}
+
+	mock.ExpectQuery(`WITH matched AS \( SELECT (.+) FROM hotels WHERE fts @@ to_tsquery\('simple', \$1\) \)(.+) ORDER BY starts_with\(lower\(text\), \$2\) DESC, word_similarity\(\$2, text\) DESC, (.+) LIMIT \$3`).
+		WithArgs("par:* & op:*", "par op", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "text", "hotel_id", "city", "country", "count"}).
+			AddRow("hotel", "Hilton Paris Opera", 42, "Paris", "fr", nil).
+			AddRow("city", "Paris", nil, nil, "fr", 310))
+
+	suggestions, err := hotelModel.Suggest("  Par OP ", 5)
+	if err != nil {
+		t.Fatalf("error was not expected while suggesting: %s", err)
+	}
+
+	expected := []*Suggestion{
+		{Type: "hotel", Text: "Hilton Paris Opera", HotelID: 42, City: "Paris", Country: "fr"},
+		{Type: "city", Text: "Paris", Country: "fr", Count: 310},
+	}
+	if !reflect.DeepEqual(suggestions, expected) {
+		t.Errorf("expected %+v, got %+v", expected, suggestions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestValidateSuggest(t *testing.T) {
+	tests := []struct {
+		name  string
+		q     string
+		limit int
+		field string
+	}{
+		{name: "valid", q: "par", limit: 10},
+		{name: "two words", q: "grand h", limit: 10},
+		{name: "missing", q: "", limit: 10, field: "q"},
+		{name: "single letter", q: "p", limit: 10, field: "q"},
+		{name: "punctuation only", q: "&!", limit: 10, field: "q"},
+		{name: "too many words", q: "a b c d e f g h i j k", limit: 10, field: "q"},
+		{name: "zero limit", q: "par", limit: 0, field: "limit"},
+		{name: "limit too large", q: "par", limit: MaxSuggestions + 1, field: "limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateSuggest(v, tt.q, tt.limit)
+
+			if tt.field == "" {
+				if !v.Valid() {
+					t.Errorf("expected no errors, got %v", v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.field]; !ok {
+				t.Errorf("expected an error for %s, got %v", tt.field, v.Errors)
+			}
+		})
+	}
+}
+
+// TestSuggestPostgres checks the suggestion query against a migrated database
+// given by NUITEE_TEST_DB_DSN.
+func TestSuggestPostgres(t *testing.T) {
+	dsn := os.Getenv("NUITEE_TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("NUITEE_TEST_DB_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	models := NewModels(db)
+
+	hotels := []*Hotel{
+		{HotelID: 2147483003, HotelName: "Zyxwa Palace", Address: Address{City: "Zyxwaville", Country: "zq"}},
+		{HotelID: 2147483004, HotelName: "Harbour Inn", Address: Address{City: "Zyxwaville", Country: "zq"}},
+	}
+	for _, hotel := range hotels {
+		if _, err := models.Hotels.Upsert(hotel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM hotels WHERE hotel_id IN ($1, $2)`, hotels[0].HotelID, hotels[1].HotelID)
+	})
+
+	suggestions, err := models.Hotels.Suggest("zyxw", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The city matches and has both hotels, but only the hotel named after it
+	// is suggested as a hotel.
+	expected := []*Suggestion{
+		{Type: "city", Text: "Zyxwaville", Country: "zq", Count: 2},
+		{Type: "hotel", Text: "Zyxwa Palace", HotelID: 2147483003, City: "Zyxwaville", Country: "zq"},
+	}
+	if len(suggestions) != len(expected) {
+		t.Fatalf("expected %d suggestions, got %+v", len(expected), suggestions)
+	}
+	for _, want := range expected {
+		if !slices.ContainsFunc(suggestions, func(s *Suggestion) bool { return *s == *want }) {
+			t.Errorf("expected %+v in %+v", want, suggestions)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SyncFailure tracks a hotel whose sync has failed on consecutive runs. A
+// hotel is quarantined once it fails too often in a row: it is then only
+// retried from NextAttemptAt on. The row is deleted as soon as the hotel
+// syncs successfully.
+type SyncFailure struct {
+	HotelID             int        `json:"hotel_id"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error"`
+	FirstFailedAt       time.Time  `json:"first_failed_at"`
+	LastFailedAt        time.Time  `json:"last_failed_at"`
+	QuarantinedAt       *time.Time `json:"quarantined_at"`
+	NextAttemptAt       *time.Time `json:"next_attempt_at"`
+}
+
+// HeldBack reports whether the hotel is quarantined and not yet due for its
+// next attempt at now.
+func (f *SyncFailure) HeldBack(now time.Time) bool {
+	return f.QuarantinedAt != nil && f.NextAttemptAt != nil && now.Before(*f.NextAttemptAt)
+}
+
+type SyncFailureModel struct {
+	DB *sql.DB
+}
+
+// Upsert records the latest failure of a hotel, replacing its previous
+// failure count, error and quarantine state.
+func (m SyncFailureModel) Upsert(failure *SyncFailure) error {
+	query := `
+		INSERT INTO sync_failures (hotel_id, consecutive_failures, last_error, quarantined_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (hotel_id) DO UPDATE SET
+			consecutive_failures = EXCLUDED.consecutive_failures,
+			last_error = EXCLUDED.last_error,
+			last_failed_at = CURRENT_TIMESTAMP,
+			quarantined_at = EXCLUDED.quarantined_at,
+			next_attempt_at = EXCLUDED.next_attempt_at
+		RETURNING first_failed_at, last_failed_at`
+
+	args := []any{
+		failure.HotelID,
+		failure.ConsecutiveFailures,
+		failure.LastError,
+		failure.QuarantinedAt,
+		failure.NextAttemptAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&failure.FirstFailedAt, &failure.LastFailedAt)
+}
+
+// Delete clears the failure record of a hotel after a successful sync.
+func (m SyncFailureModel) Delete(hotelID int) error {
+	query := `
+		DELETE FROM sync_failures
+		WHERE hotel_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, hotelID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAllByHotel returns every failure record keyed by hotel ID. The sync job
+// reads it once per run to decide which hotels to hold back.
+func (m SyncFailureModel) GetAllByHotel() (map[int]*SyncFailure, error) {
+	query := `
+		SELECT hotel_id, consecutive_failures, last_error, first_failed_at, last_failed_at, quarantined_at, next_attempt_at
+		FROM sync_failures`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := map[int]*SyncFailure{}
+
+	for rows.Next() {
+		var failure SyncFailure
+
+		err := rows.Scan(
+			&failure.HotelID,
+			&failure.ConsecutiveFailures,
+			&failure.LastError,
+			&failure.FirstFailedAt,
+			&failure.LastFailedAt,
+			&failure.QuarantinedAt,
+			&failure.NextAttemptAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		failures[failure.HotelID] = &failure
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+// GetAllQuarantined returns the quarantined hotels.
+func (m SyncFailureModel) GetAllQuarantined(filters Filters) ([]*SyncFailure, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), hotel_id, consecutive_failures, last_error, first_failed_at, last_failed_at, quarantined_at, next_attempt_at
+		FROM sync_failures
+		WHERE quarantined_at IS NOT NULL
+		ORDER BY %s %s, hotel_id ASC
+		LIMIT $1 OFFSET $2`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	failures := []*SyncFailure{}
+
+	for rows.Next() {
+		var failure SyncFailure
+
+		err := rows.Scan(
+			&totalRecords,
+			&failure.HotelID,
+			&failure.ConsecutiveFailures,
+			&failure.LastError,
+			&failure.FirstFailedAt,
+			&failure.LastFailedAt,
+			&failure.QuarantinedAt,
+			&failure.NextAttemptAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		failures = append(failures, &failure)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+
+	return failures, metadata, nil
+}
+
+// Requeue releases the given hotels from quarantine so the next run syncs
+// them again. Their failure count starts over, but the record and last error
+// are kept until they sync successfully. It returns the IDs of the hotels
+// that were quarantined and have been released.
+func (m SyncFailureModel) Requeue(hotelIDs []int) ([]int, error) {
+	query := `
+		UPDATE sync_failures
+		SET consecutive_failures = 0, quarantined_at = NULL, next_attempt_at = NULL
+		WHERE quarantined_at IS NOT NULL AND hotel_id = ANY($1)
+		RETURNING hotel_id`
+
+	return m.requeue(query, pq.Array(hotelIDs))
+}
+
+// RequeueAll releases every quarantined hotel, see Requeue.
+func (m SyncFailureModel) RequeueAll() ([]int, error) {
+	query := `
+		UPDATE sync_failures
+		SET consecutive_failures = 0, quarantined_at = NULL, next_attempt_at = NULL
+		WHERE quarantined_at IS NOT NULL
+		RETURNING hotel_id`
+
+	return m.requeue(query)
+}
+
+func (m SyncFailureModel) requeue(query string, args ...any) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requeued := []int{}
+
+	for rows.Next() {
+		var hotelID int
+		if err := rows.Scan(&hotelID); err != nil {
+			return nil, err
+		}
+		requeued = append(requeued, hotelID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requeued, nil
+}
@@ -0,0 +1,194 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var syncFailureColumns = []string{
+	"hotel_id", "consecutive_failures", "last_error", "first_failed_at", "last_failed_at", "quarantined_at", "next_attempt_at",
+}
+
+func TestSyncFailure_HeldBack(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		failure  SyncFailure
+		expected bool
+	}{
+		{name: "not quarantined", failure: SyncFailure{ConsecutiveFailures: 2}},
+		{name: "quarantined, not due", failure: SyncFailure{QuarantinedAt: &past, NextAttemptAt: &future}, expected: true},
+		{name: "quarantined, due", failure: SyncFailure{QuarantinedAt: &past, NextAttemptAt: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.failure.HeldBack(now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSyncFailureModel_UpsertAndDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncFailureModel{DB: db}
+
+	now := time.Now()
+	next := now.Add(time.Hour)
+	failure := &SyncFailure{HotelID: 123, ConsecutiveFailures: 5, LastError: "unexpected status code: 500", QuarantinedAt: &now, NextAttemptAt: &next}
+
+	mock.ExpectQuery(`INSERT INTO sync_failures (.+) VALUES \(\$1, \$2, \$3, \$4, \$5\) ON CONFLICT \(hotel_id\) DO UPDATE`).
+		WithArgs(123, 5, "unexpected status code: 500", &now, &next).
+		WillReturnRows(sqlmock.NewRows([]string{"first_failed_at", "last_failed_at"}).AddRow(now.Add(-time.Hour), now))
+
+	err = model.Upsert(failure)
+	if err != nil {
+		t.Fatalf("error was not expected while upserting failure: %s", err)
+	}
+	if !failure.LastFailedAt.Equal(now) {
+		t.Errorf("expected LastFailedAt to be set, got %v", failure.LastFailedAt)
+	}
+
+	mock.ExpectExec(`DELETE FROM sync_failures WHERE hotel_id = \$1`).
+		WithArgs(123).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM sync_failures WHERE hotel_id = \$1`).
+		WithArgs(456).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := model.Delete(123); err != nil {
+		t.Errorf("error was not expected while deleting failure: %s", err)
+	}
+	if err := model.Delete(456); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncFailureModel_GetAllByHotel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncFailureModel{DB: db}
+
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+) FROM sync_failures$`).
+		WillReturnRows(sqlmock.NewRows(syncFailureColumns).
+			AddRow(1, 2, "timeout", now, now, nil, nil).
+			AddRow(2, 6, "not found", now, now, now, now.Add(time.Hour)))
+
+	failures, err := model.GetAllByHotel()
+	if err != nil {
+		t.Fatalf("error was not expected while getting failures: %s", err)
+	}
+
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures, got %d", len(failures))
+	}
+	if failures[1].QuarantinedAt != nil {
+		t.Error("expected hotel 1 not to be quarantined")
+	}
+	if !failures[2].HeldBack(now) {
+		t.Error("expected hotel 2 to be held back")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncFailureModel_GetAllQuarantined(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncFailureModel{DB: db}
+
+	filters := Filters{
+		Page:         2,
+		PageSize:     10,
+		Sort:         "-consecutive_failures",
+		SortSafelist: []string{"consecutive_failures", "-consecutive_failures"},
+	}
+
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), (.+) FROM sync_failures WHERE quarantined_at IS NOT NULL ORDER BY consecutive_failures DESC, hotel_id ASC LIMIT \$1 OFFSET \$2`).
+		WithArgs(10, 10).
+		WillReturnRows(sqlmock.NewRows(append([]string{"count"}, syncFailureColumns...)).
+			AddRow(11, 2, 6, "not found", now, now, now, now.Add(time.Hour)))
+
+	failures, metadata, err := model.GetAllQuarantined(filters)
+	if err != nil {
+		t.Fatalf("error was not expected while getting quarantined hotels: %s", err)
+	}
+
+	if len(failures) != 1 || failures[0].HotelID != 2 {
+		t.Errorf("unexpected failures: %+v", failures)
+	}
+	if metadata.TotalRecords != 11 {
+		t.Errorf("expected TotalRecords to be 11, got %d", metadata.TotalRecords)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncFailureModel_Requeue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncFailureModel{DB: db}
+
+	mock.ExpectQuery(`UPDATE sync_failures SET (.+) WHERE quarantined_at IS NOT NULL AND hotel_id = ANY\(\$1\) RETURNING hotel_id`).
+		WithArgs("{1,2,3}").
+		WillReturnRows(sqlmock.NewRows([]string{"hotel_id"}).AddRow(1).AddRow(3))
+
+	requeued, err := model.Requeue([]int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("error was not expected while requeueing: %s", err)
+	}
+	if len(requeued) != 2 || requeued[0] != 1 || requeued[1] != 3 {
+		t.Errorf("expected [1 3] to be requeued, got %v", requeued)
+	}
+
+	mock.ExpectQuery(`UPDATE sync_failures SET (.+) WHERE quarantined_at IS NOT NULL RETURNING hotel_id`).
+		WillReturnRows(sqlmock.NewRows([]string{"hotel_id"}))
+
+	requeued, err = model.RequeueAll()
+	if err != nil {
+		t.Fatalf("error was not expected while requeueing: %s", err)
+	}
+	if len(requeued) != 0 {
+		t.Errorf("expected nothing to be requeued, got %v", requeued)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,266 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+const (
+	SyncOutcomeSucceeded = "succeeded"
+	SyncOutcomeFailed    = "failed"
+	SyncOutcomeSkipped   = "skipped"
+)
+
+type SyncRun struct {
+	ID              int64      `json:"id"`
+	Tier            string     `json:"tier"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+	HotelsTotal     int        `json:"hotels_total"`
+	Succeeded       int        `json:"succeeded"`
+	Failed          int        `json:"failed"`
+	Skipped         int        `json:"skipped"`
+	ReviewsInserted int        `json:"reviews_inserted"`
+	ReviewsUpdated  int        `json:"reviews_updated"`
+	ReviewsRemoved  int        `json:"reviews_removed"`
+}
+
+type SyncRunItem struct {
+	ID              int64     `json:"id"`
+	RunID           int64     `json:"run_id"`
+	HotelID         int       `json:"hotel_id"`
+	Outcome         string    `json:"outcome"`
+	Error           string    `json:"error,omitempty"`
+	ReviewsInserted int       `json:"reviews_inserted"`
+	ReviewsUpdated  int       `json:"reviews_updated"`
+	ReviewsRemoved  int       `json:"reviews_removed"`
+	DurationMs      int64     `json:"duration_ms"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type SyncRunModel struct {
+	DB *sql.DB
+}
+
+func ValidateSyncOutcome(v *validator.Validator, outcome string) {
+	v.Check(outcome == "" || validator.PermittedValue(outcome, SyncOutcomeSucceeded, SyncOutcomeFailed, SyncOutcomeSkipped), "outcome", "invalid outcome value")
+}
+
+func (m SyncRunModel) Insert(run *SyncRun) error {
+	query := `
+		INSERT INTO sync_runs (tier, hotels_total)
+		VALUES ($1, $2)
+		RETURNING id, started_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, run.Tier, run.HotelsTotal).Scan(&run.ID, &run.StartedAt)
+}
+
+func (m SyncRunModel) Finish(run *SyncRun) error {
+	query := `
+		UPDATE sync_runs
+		SET finished_at = CURRENT_TIMESTAMP, succeeded = $1, failed = $2, skipped = $3, reviews_inserted = $4, reviews_updated = $5, reviews_removed = $6
+		WHERE id = $7
+		RETURNING finished_at`
+
+	args := []any{
+		run.Succeeded,
+		run.Failed,
+		run.Skipped,
+		run.ReviewsInserted,
+		run.ReviewsUpdated,
+		run.ReviewsRemoved,
+		run.ID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&run.FinishedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m SyncRunModel) InsertItem(item *SyncRunItem) error {
+	query := `
+		INSERT INTO sync_run_items (run_id, hotel_id, outcome, error, reviews_inserted, reviews_updated, reviews_removed, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+
+	args := []any{
+		item.RunID,
+		item.HotelID,
+		item.Outcome,
+		item.Error,
+		item.ReviewsInserted,
+		item.ReviewsUpdated,
+		item.ReviewsRemoved,
+		item.DurationMs,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&item.ID, &item.CreatedAt)
+}
+
+func (m SyncRunModel) Get(id int64) (*SyncRun, error) {
+	if id <= 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, tier, started_at, finished_at, hotels_total, succeeded, failed, skipped, reviews_inserted, reviews_updated, reviews_removed
+		FROM sync_runs
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var run SyncRun
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&run.ID,
+		&run.Tier,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.HotelsTotal,
+		&run.Succeeded,
+		&run.Failed,
+		&run.Skipped,
+		&run.ReviewsInserted,
+		&run.ReviewsUpdated,
+		&run.ReviewsRemoved,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &run, nil
+}
+
+func (m SyncRunModel) GetAll(filters Filters) ([]*SyncRun, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, tier, started_at, finished_at, hotels_total, succeeded, failed, skipped, reviews_inserted, reviews_updated, reviews_removed
+		FROM sync_runs
+		ORDER BY %s %s, id DESC
+		LIMIT $1 OFFSET $2`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	runs := []*SyncRun{}
+
+	for rows.Next() {
+		var run SyncRun
+
+		err := rows.Scan(
+			&totalRecords,
+			&run.ID,
+			&run.Tier,
+			&run.StartedAt,
+			&run.FinishedAt,
+			&run.HotelsTotal,
+			&run.Succeeded,
+			&run.Failed,
+			&run.Skipped,
+			&run.ReviewsInserted,
+			&run.ReviewsUpdated,
+			&run.ReviewsRemoved,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		runs = append(runs, &run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+
+	return runs, metadata, nil
+}
+
+// GetItems returns the per-hotel results recorded for a run, optionally
+// narrowed down to a single outcome.
+func (m SyncRunModel) GetItems(runID int64, outcome string, filters Filters) ([]*SyncRunItem, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, run_id, hotel_id, outcome, error, reviews_inserted, reviews_updated, reviews_removed, duration_ms, created_at
+		FROM sync_run_items
+		WHERE run_id = $1 AND (outcome = $2 OR $2 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, runID, outcome, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	items := []*SyncRunItem{}
+
+	for rows.Next() {
+		var item SyncRunItem
+
+		err := rows.Scan(
+			&totalRecords,
+			&item.ID,
+			&item.RunID,
+			&item.HotelID,
+			&item.Outcome,
+			&item.Error,
+			&item.ReviewsInserted,
+			&item.ReviewsUpdated,
+			&item.ReviewsRemoved,
+			&item.DurationMs,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		items = append(items, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+
+	return items, metadata, nil
+}
@@ -0,0 +1,306 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JLL32/nuitee/internal/validator"
+)
+
+var syncRunColumns = []string{
+	"id", "tier", "started_at", "finished_at", "hotels_total", "succeeded", "failed", "skipped", "reviews_inserted", "reviews_updated", "reviews_removed",
+}
+
+func TestSyncRunModel_Insert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	startedAt := time.Now()
+	mock.ExpectQuery(`INSERT INTO sync_runs \(tier, hotels_total\) VALUES \(\$1, \$2\) RETURNING id, started_at`).
+		WithArgs("popular", 42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(7, startedAt))
+
+	run := &SyncRun{Tier: "popular", HotelsTotal: 42}
+	err = model.Insert(run)
+	if err != nil {
+		t.Errorf("error was not expected while inserting sync run: %s", err)
+	}
+
+	if run.ID != 7 {
+		t.Errorf("expected ID to be 7, got %d", run.ID)
+	}
+
+	if run.StartedAt != startedAt {
+		t.Errorf("expected StartedAt to be %v, got %v", startedAt, run.StartedAt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncRunModel_Finish(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	finishedAt := time.Now()
+	mock.ExpectQuery(`UPDATE sync_runs SET finished_at = CURRENT_TIMESTAMP`).
+		WithArgs(10, 2, 1, 30, 5, 4, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(finishedAt))
+
+	run := &SyncRun{ID: 7, Succeeded: 10, Failed: 2, Skipped: 1, ReviewsInserted: 30, ReviewsUpdated: 5, ReviewsRemoved: 4}
+	err = model.Finish(run)
+	if err != nil {
+		t.Errorf("error was not expected while finishing sync run: %s", err)
+	}
+
+	if run.FinishedAt == nil || !run.FinishedAt.Equal(finishedAt) {
+		t.Errorf("expected FinishedAt to be %v, got %v", finishedAt, run.FinishedAt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncRunModel_Finish_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	mock.ExpectQuery(`UPDATE sync_runs`).
+		WillReturnError(sql.ErrNoRows)
+
+	err = model.Finish(&SyncRun{ID: 99})
+	if err != ErrRecordNotFound {
+		t.Errorf("expected error to be %v, got %v", ErrRecordNotFound, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncRunModel_InsertItem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	item := &SyncRunItem{
+		RunID:           7,
+		HotelID:         123,
+		Outcome:         SyncOutcomeFailed,
+		Error:           "fetching hotel data: unexpected status code: 404",
+		ReviewsInserted: 0,
+		ReviewsUpdated:  0,
+		ReviewsRemoved:  0,
+		DurationMs:      250,
+	}
+
+	mock.ExpectQuery(`INSERT INTO sync_run_items`).
+		WithArgs(item.RunID, item.HotelID, item.Outcome, item.Error, item.ReviewsInserted, item.ReviewsUpdated, item.ReviewsRemoved, item.DurationMs).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+
+	err = model.InsertItem(item)
+	if err != nil {
+		t.Errorf("error was not expected while inserting sync run item: %s", err)
+	}
+
+	if item.ID != 1 {
+		t.Errorf("expected ID to be 1, got %d", item.ID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncRunModel_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	startedAt := time.Now().Add(-time.Minute)
+	finishedAt := time.Now()
+
+	mock.ExpectQuery(`SELECT id, tier, started_at, finished_at, hotels_total, succeeded, failed, skipped, reviews_inserted, reviews_updated, reviews_removed FROM sync_runs WHERE id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(syncRunColumns).AddRow(7, DefaultTier, startedAt, finishedAt, 3, 2, 1, 0, 10, 4, 2))
+
+	run, err := model.Get(7)
+	if err != nil {
+		t.Fatalf("error was not expected while getting sync run: %s", err)
+	}
+
+	if run.ID != 7 || run.HotelsTotal != 3 || run.Failed != 1 || run.ReviewsInserted != 10 || run.ReviewsRemoved != 2 || run.Tier != DefaultTier {
+		t.Errorf("unexpected run: %+v", run)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncRunModel_Get_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	mock.ExpectQuery(`SELECT (.+) FROM sync_runs WHERE id = \$1`).
+		WithArgs(int64(99)).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = model.Get(99)
+	if err != ErrRecordNotFound {
+		t.Errorf("expected error to be %v, got %v", ErrRecordNotFound, err)
+	}
+
+	_, err = model.Get(0)
+	if err != ErrRecordNotFound {
+		t.Errorf("expected error to be %v for an invalid ID, got %v", ErrRecordNotFound, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncRunModel_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "-id",
+		SortSafelist: []string{"id", "-id"},
+	}
+
+	rows := sqlmock.NewRows(append([]string{"count"}, syncRunColumns...)).
+		AddRow(2, 8, DefaultTier, time.Now(), nil, 5, 0, 0, 0, 0, 0, 0).
+		AddRow(2, 7, "popular", time.Now(), time.Now(), 5, 4, 1, 0, 12, 3, 1)
+
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, tier, started_at, finished_at, (.+) FROM sync_runs ORDER BY id DESC, id DESC LIMIT \$1 OFFSET \$2`).
+		WithArgs(20, 0).
+		WillReturnRows(rows)
+
+	runs, metadata, err := model.GetAll(filters)
+	if err != nil {
+		t.Fatalf("error was not expected while getting sync runs: %s", err)
+	}
+
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(runs))
+	}
+
+	if runs[0].FinishedAt != nil {
+		t.Error("expected an in-progress run to have no FinishedAt")
+	}
+
+	if metadata.TotalRecords != 2 {
+		t.Errorf("expected TotalRecords to be 2, got %d", metadata.TotalRecords)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncRunModel_GetItems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := SyncRunModel{DB: db}
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     100,
+		Sort:         "id",
+		SortSafelist: []string{"id", "-id"},
+	}
+
+	rows := sqlmock.NewRows([]string{
+		"count", "id", "run_id", "hotel_id", "outcome", "error", "reviews_inserted", "reviews_updated", "reviews_removed", "duration_ms", "created_at",
+	}).AddRow(1, 3, 7, 123, SyncOutcomeFailed, "unexpected status code: 500", 0, 0, 0, 1200, time.Now())
+
+	mock.ExpectQuery(`SELECT count\(\*\) OVER\(\), id, run_id, hotel_id, (.+) FROM sync_run_items WHERE run_id = \$1 AND \(outcome = \$2 OR \$2 = ''\) ORDER BY id ASC, id ASC LIMIT \$3 OFFSET \$4`).
+		WithArgs(int64(7), SyncOutcomeFailed, 100, 0).
+		WillReturnRows(rows)
+
+	items, metadata, err := model.GetItems(7, SyncOutcomeFailed, filters)
+	if err != nil {
+		t.Fatalf("error was not expected while getting sync run items: %s", err)
+	}
+
+	if len(items) != 1 || items[0].HotelID != 123 || items[0].Error == "" {
+		t.Errorf("unexpected items: %+v", items)
+	}
+
+	if metadata.TotalRecords != 1 {
+		t.Errorf("expected TotalRecords to be 1, got %d", metadata.TotalRecords)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestValidateSyncOutcome(t *testing.T) {
+	tests := []struct {
+		outcome string
+		valid   bool
+	}{
+		{"", true},
+		{SyncOutcomeSucceeded, true},
+		{SyncOutcomeFailed, true},
+		{SyncOutcomeSkipped, true},
+		{"crashed", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.outcome, func(t *testing.T) {
+			v := validator.New()
+			ValidateSyncOutcome(v, tt.outcome)
+
+			if v.Valid() != tt.valid {
+				t.Errorf("expected valid to be %v, got errors %v", tt.valid, v.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DefaultTier is the refresh tier of hotels that have not been assigned one.
+const DefaultTier = "default"
+
+// TrackedHotel is a hotel the sync job keeps up to date, and the refresh
+// tier that decides how often it is synced.
+type TrackedHotel struct {
+	HotelID int
+	Tier    string
+}
+
+// TrackedHotelModel reads the list of hotels the sync job keeps up to date.
+// Rows are managed directly in the database; disabling a row stops it being
+// synced without losing it.
+type TrackedHotelModel struct {
+	DB *sql.DB
+}
+
+func (m TrackedHotelModel) GetAll() ([]TrackedHotel, error) {
+	query := `
+		SELECT hotel_id, tier
+		FROM tracked_hotels
+		WHERE enabled
+		ORDER BY hotel_id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hotels := []TrackedHotel{}
+
+	for rows.Next() {
+		var hotel TrackedHotel
+		if err := rows.Scan(&hotel.HotelID, &hotel.Tier); err != nil {
+			return nil, err
+		}
+		hotels = append(hotels, hotel)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hotels, nil
+}
@@ -0,0 +1,47 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTrackedHotelModel_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	model := TrackedHotelModel{DB: db}
+
+	mock.ExpectQuery(`SELECT hotel_id, tier FROM tracked_hotels WHERE enabled ORDER BY hotel_id ASC`).
+		WillReturnRows(sqlmock.NewRows([]string{"hotel_id", "tier"}).
+			AddRow(1, "popular").
+			AddRow(5, DefaultTier).
+			AddRow(9, "longtail"))
+
+	hotels, err := model.GetAll()
+	if err != nil {
+		t.Fatalf("error was not expected while getting tracked hotels: %s", err)
+	}
+
+	expected := []TrackedHotel{{1, "popular"}, {5, DefaultTier}, {9, "longtail"}}
+	if !reflect.DeepEqual(hotels, expected) {
+		t.Errorf("expected %v, got %v", expected, hotels)
+	}
+
+	mock.ExpectQuery(`SELECT hotel_id, tier FROM tracked_hotels`).
+		WillReturnError(errors.New("connection refused"))
+
+	_, err = model.GetAll()
+	if err == nil {
+		t.Error("expected an error when the query fails")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,27 @@
+CREATE TABLE sync_runs (
+    id BIGSERIAL PRIMARY KEY,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    hotels_total INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    reviews_inserted INTEGER NOT NULL DEFAULT 0,
+    reviews_updated INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE sync_run_items (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL,
+    hotel_id INTEGER NOT NULL,
+    outcome TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    reviews_inserted INTEGER NOT NULL DEFAULT 0,
+    reviews_updated INTEGER NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (run_id) REFERENCES sync_runs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sync_run_items_run_id ON sync_run_items (run_id);
+CREATE INDEX idx_sync_run_items_hotel_id ON sync_run_items (hotel_id);
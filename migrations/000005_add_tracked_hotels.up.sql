@@ -0,0 +1,5 @@
+CREATE TABLE tracked_hotels (
+    hotel_id INTEGER PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+ALTER TABLE sync_run_items DROP COLUMN IF EXISTS reviews_removed;
+ALTER TABLE sync_runs DROP COLUMN IF EXISTS reviews_removed;
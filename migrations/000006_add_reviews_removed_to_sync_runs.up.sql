@@ -0,0 +1,2 @@
+ALTER TABLE sync_runs ADD COLUMN reviews_removed INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_run_items ADD COLUMN reviews_removed INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+CREATE TABLE hotel_changes (
+    id BIGSERIAL PRIMARY KEY,
+    hotel_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (hotel_id) REFERENCES hotels(hotel_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_hotel_changes_hotel_id ON hotel_changes (hotel_id, changed_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tracked_hotels_tier;
+
+ALTER TABLE sync_runs DROP COLUMN IF EXISTS tier;
+ALTER TABLE tracked_hotels DROP COLUMN IF EXISTS tier;
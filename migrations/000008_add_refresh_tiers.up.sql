@@ -0,0 +1,4 @@
+ALTER TABLE tracked_hotels ADD COLUMN tier TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE sync_runs ADD COLUMN tier TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_tracked_hotels_tier ON tracked_hotels (tier);
@@ -0,0 +1,9 @@
+CREATE TABLE hotel_sync_state (
+    hotel_id INTEGER PRIMARY KEY,
+    newest_review_at TIMESTAMP,
+    review_count_offset INTEGER NOT NULL DEFAULT 0,
+    full_resync_due BOOLEAN NOT NULL DEFAULT false,
+    last_full_sync_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (hotel_id) REFERENCES hotels(hotel_id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+CREATE TABLE sync_failures (
+    hotel_id INTEGER PRIMARY KEY,
+    consecutive_failures INTEGER NOT NULL,
+    last_error TEXT NOT NULL,
+    first_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    quarantined_at TIMESTAMP,
+    next_attempt_at TIMESTAMP
+);
+
+CREATE INDEX idx_sync_failures_quarantined ON sync_failures (next_attempt_at) WHERE quarantined_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS hotel_policies;
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS hotel_facilities;
+DROP TABLE IF EXISTS hotel_photos;
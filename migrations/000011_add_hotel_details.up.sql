@@ -0,0 +1,48 @@
+CREATE TABLE hotel_photos (
+    hotel_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    hd_url TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    class TEXT NOT NULL DEFAULT '',
+    main_photo BOOLEAN NOT NULL DEFAULT false,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (hotel_id, position),
+    FOREIGN KEY (hotel_id) REFERENCES hotels(hotel_id) ON DELETE CASCADE
+);
+
+CREATE TABLE hotel_facilities (
+    hotel_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    facility_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (hotel_id, position),
+    FOREIGN KEY (hotel_id) REFERENCES hotels(hotel_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_hotel_facilities_facility_id ON hotel_facilities (facility_id);
+
+CREATE TABLE rooms (
+    hotel_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    room_id BIGINT NOT NULL,
+    room_name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    room_size_square DOUBLE PRECISION NOT NULL DEFAULT 0,
+    room_size_unit TEXT NOT NULL DEFAULT '',
+    max_adults INTEGER NOT NULL DEFAULT 0,
+    max_children INTEGER NOT NULL DEFAULT 0,
+    max_occupancy INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (hotel_id, position),
+    FOREIGN KEY (hotel_id) REFERENCES hotels(hotel_id) ON DELETE CASCADE
+);
+
+CREATE TABLE hotel_policies (
+    hotel_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    policy_type TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (hotel_id, position),
+    FOREIGN KEY (hotel_id) REFERENCES hotels(hotel_id) ON DELETE CASCADE
+);
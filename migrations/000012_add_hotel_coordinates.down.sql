@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_hotels_latitude;
+
+ALTER TABLE hotels DROP COLUMN IF EXISTS longitude;
+ALTER TABLE hotels DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,6 @@
+ALTER TABLE hotels ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE hotels ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+-- Radius searches first narrow hotels down to a latitude band around the
+-- point, which this index serves. The exact distance is computed in SQL.
+CREATE INDEX IF NOT EXISTS idx_hotels_latitude ON hotels (latitude);
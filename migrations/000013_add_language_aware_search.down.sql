@@ -0,0 +1,28 @@
+DROP INDEX IF EXISTS idx_fts_hotels;
+ALTER TABLE hotels DROP COLUMN IF EXISTS fts;
+ALTER TABLE hotels ADD COLUMN fts tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('simple',
+      coalesce(hotel_name, '') || ' ' ||
+      coalesce(city, '') || ' ' ||
+      coalesce(country, '') || ' ' ||
+      coalesce(description, '')
+    )
+  ) STORED;
+
+CREATE INDEX idx_fts_hotels ON hotels USING gin (fts);
+
+DROP INDEX IF EXISTS idx_fts_reviews;
+ALTER TABLE reviews DROP COLUMN IF EXISTS fts;
+ALTER TABLE reviews ADD COLUMN fts tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('simple',
+      coalesce(headline, '') || ' ' ||
+      coalesce(pros, '') || ' ' ||
+      coalesce(cons, '')
+    )
+  ) STORED;
+
+CREATE INDEX idx_fts_reviews ON reviews USING gin (fts);
+
+DROP FUNCTION IF EXISTS review_search_config(TEXT);
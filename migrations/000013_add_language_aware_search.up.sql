@@ -0,0 +1,68 @@
+-- Picks the text search configuration for a review from its language code
+-- ("en", "fr", "pt-BR", ...). Languages Postgres has no stemmer for keep the
+-- 'simple' configuration. The same function is used on the query side so
+-- both are stemmed alike.
+CREATE OR REPLACE FUNCTION review_search_config(language TEXT) RETURNS regconfig
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+  SELECT CASE lower(split_part(split_part(coalesce(language, ''), '-', 1), '_', 1))
+    WHEN 'da' THEN 'pg_catalog.danish'
+    WHEN 'de' THEN 'pg_catalog.german'
+    WHEN 'en' THEN 'pg_catalog.english'
+    WHEN 'es' THEN 'pg_catalog.spanish'
+    WHEN 'fi' THEN 'pg_catalog.finnish'
+    WHEN 'fr' THEN 'pg_catalog.french'
+    WHEN 'hu' THEN 'pg_catalog.hungarian'
+    WHEN 'it' THEN 'pg_catalog.italian'
+    WHEN 'nl' THEN 'pg_catalog.dutch'
+    WHEN 'no' THEN 'pg_catalog.norwegian'
+    WHEN 'nb' THEN 'pg_catalog.norwegian'
+    WHEN 'nn' THEN 'pg_catalog.norwegian'
+    WHEN 'pt' THEN 'pg_catalog.portuguese'
+    WHEN 'ro' THEN 'pg_catalog.romanian'
+    WHEN 'ru' THEN 'pg_catalog.russian'
+    WHEN 'sv' THEN 'pg_catalog.swedish'
+    WHEN 'tr' THEN 'pg_catalog.turkish'
+    ELSE 'pg_catalog.simple'
+  END::regconfig
+$$;
+
+-- Generated columns cannot be altered in place, so both are rebuilt along
+-- with their indexes.
+DROP INDEX IF EXISTS idx_fts_reviews;
+ALTER TABLE reviews DROP COLUMN IF EXISTS fts;
+ALTER TABLE reviews ADD COLUMN fts tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector(review_search_config(language),
+      coalesce(headline, '') || ' ' ||
+      coalesce(pros, '') || ' ' ||
+      coalesce(cons, '')
+    )
+  ) STORED;
+
+CREATE INDEX idx_fts_reviews ON reviews USING gin (fts);
+
+-- Hotels have no language column and Cupid serves descriptions in English.
+-- Every field is indexed twice, unstemmed so proper nouns match exactly and
+-- stemmed as English. A search is parsed both ways and matched as a whole
+-- against each half, so each half must hold all the fields: otherwise
+-- "spa paris" could not match a description word and a city together, and
+-- "spa -paris" would exclude the city from one half only.
+DROP INDEX IF EXISTS idx_fts_hotels;
+ALTER TABLE hotels DROP COLUMN IF EXISTS fts;
+ALTER TABLE hotels ADD COLUMN fts tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('simple',
+      coalesce(hotel_name, '') || ' ' ||
+      coalesce(city, '') || ' ' ||
+      coalesce(country, '') || ' ' ||
+      coalesce(description, '')
+    ) ||
+    to_tsvector('english',
+      coalesce(hotel_name, '') || ' ' ||
+      coalesce(city, '') || ' ' ||
+      coalesce(country, '') || ' ' ||
+      coalesce(description, '')
+    )
+  ) STORED;
+
+CREATE INDEX idx_fts_hotels ON hotels USING gin (fts);
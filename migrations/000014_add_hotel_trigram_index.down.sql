@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_hotels_trgm;
+
+-- pg_trgm is left installed since other database objects may depend on it.
@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Fuzzy hotel searches compare the search to the name and city by trigram
+-- word similarity. The indexed expression must match hotelTrigramText in
+-- internal/data for the index to be used.
+CREATE INDEX IF NOT EXISTS idx_hotels_trgm ON hotels USING gin ((hotel_name || ' ' || coalesce(city, '')) gin_trgm_ops);